@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -10,8 +12,14 @@ import (
 	_ "github.com/CardenalDex/crudprotec/docs"
 
 	config "github.com/CardenalDex/crudprotec/cmd"
+	"github.com/CardenalDex/crudprotec/internal/adapter/archive"
+	"github.com/CardenalDex/crudprotec/internal/adapter/bundle"
+	"github.com/CardenalDex/crudprotec/internal/adapter/cfdi"
 	"github.com/CardenalDex/crudprotec/internal/adapter/handler"
+	"github.com/CardenalDex/crudprotec/internal/adapter/outbox"
 	"github.com/CardenalDex/crudprotec/internal/adapter/repository"
+	"github.com/CardenalDex/crudprotec/internal/adapter/scheduler"
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
 	"github.com/CardenalDex/crudprotec/internal/usecase"
 )
 
@@ -27,18 +35,94 @@ func main() {
 		log.Fatalf("Config error: %s", err)
 	}
 
-	db := repository.InitInternalDB()
+	db := repository.InitInternalDB(cfg.DatabaseDir)
 
 	sqliteRepo := repository.NewSQLiteRepository(db)
 
-	txService := usecase.NewTransactionService(sqliteRepo, sqliteRepo, sqliteRepo, sqliteRepo)
-	adService := usecase.NewAdminService(sqliteRepo, sqliteRepo)
-	merchantService := usecase.NewMerchantService(sqliteRepo, sqliteRepo, sqliteRepo)
+	txService := usecase.NewTransactionService(sqliteRepo, sqliteRepo, sqliteRepo, sqliteRepo, sqliteRepo, sqliteRepo, sqliteRepo)
+	adService := usecase.NewAdminService(sqliteRepo, sqliteRepo, sqliteRepo, sqliteRepo)
+	merchantService := usecase.NewMerchantService(sqliteRepo, sqliteRepo, sqliteRepo, sqliteRepo)
+	terminalService := usecase.NewTerminalService(sqliteRepo, sqliteRepo, sqliteRepo, sqliteRepo, sqliteRepo)
+	var bundles usecase.AuditBundleWriter
+	if w, err := bundle.NewWriter(cfg.AuditSigningKey); err == nil {
+		bundles = w
+	} else {
+		log.Printf("[AUDIT] export bundles disabled: %s", err)
+	}
+	auditService := usecase.NewAuditService(sqliteRepo, sqliteRepo, sqliteRepo, archive.NewStore(cfg.AuditArchiveDir), bundles, sqliteRepo)
+	accessLogService := usecase.NewAccessLogService(sqliteRepo)
 
-	txHandler := handler.NewTransactionHandler(txService)
-	adminHandler := handler.NewAdminHandler(adService)
+	cfdiRenderer, err := cfdi.NewRenderer()
+	if err != nil {
+		log.Fatalf("CFDI schema error: %s", err)
+	}
+	issuer := entity.InvoiceIssuer{
+		RFC:         cfg.IssuerRFC,
+		Name:        cfg.IssuerName,
+		TaxRegime:   cfg.IssuerTaxRegime,
+		PostalCode:  cfg.IssuerPostalCode,
+		CertNumber:  cfg.IssuerCertNumber,
+		Series:      cfg.InvoiceSeries,
+		ProductCode: cfg.InvoiceProductKey,
+	}
+	reportService := usecase.NewReportService(sqliteRepo, sqliteRepo)
+	simulatorService := usecase.NewSimulatorService(sqliteRepo, sqliteRepo)
+	scheduledReportService := usecase.NewScheduledReportService(sqliteRepo, sqliteRepo, sqliteRepo, outbox.NewWriter(cfg.ReportsOutboxDir))
+	invoiceService := usecase.NewInvoiceService(issuer, sqliteRepo, sqliteRepo, sqliteRepo, cfdiRenderer, cfdi.UnstampedStamper{})
 
-	merchantHandler := handler.NewMerchantHandler(merchantService)
+	txHandler := handler.NewTransactionHandler(txService)
+	adminHandler := handler.NewAdminHandler(adService, auditService)
+
+	merchantHandler := handler.NewMerchantHandler(merchantService, auditService)
+	terminalHandler := handler.NewTerminalHandler(terminalService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	accessLogHandler := handler.NewAccessLogHandler(accessLogService, cfg.AccessLogRoutes)
+	invoiceHandler := handler.NewInvoiceHandler(invoiceService)
+	reportHandler := handler.NewReportHandler(reportService, scheduledReportService)
+	simulatorHandler := handler.NewSimulatorHandler(simulatorService)
+
+	// Nightly reports into the outbox
+	cron := scheduler.New(cfg.SchedulerMaxRetries, cfg.SchedulerRetryDelay)
+	reportSchedules := map[entity.ScheduledReport]string{
+		entity.ReportDailyRevenue:       cfg.ReportDailyRevenueCron,
+		entity.ReportMerchantStatements: cfg.ReportMerchantStatementsCron,
+		entity.ReportReconciliation:     cfg.ReportReconciliationCron,
+	}
+	for report, spec := range reportSchedules {
+		err := cron.Add(string(report), spec, func(ctx context.Context, scheduledFor time.Time, attempt int) error {
+			_, err := scheduledReportService.RunReport(ctx, report, scheduledFor, attempt)
+			return err
+		})
+		if err != nil {
+			log.Fatalf("Scheduler error: %s", err)
+		}
+	}
+	err = cron.Add("audit_anchor", cfg.AuditAnchorCron, func(ctx context.Context, _ time.Time, _ int) error {
+		_, err := auditService.CreateAnchor(ctx)
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Scheduler error: %s", err)
+	}
+	if cfg.AuditRetentionDays > 0 {
+		err = cron.Add("audit_archive", cfg.AuditArchiveCron, func(ctx context.Context, scheduledFor time.Time, _ int) error {
+			_, err := auditService.ArchiveLogs(ctx, scheduledFor.AddDate(0, 0, -cfg.AuditRetentionDays))
+			return err
+		})
+		if err != nil {
+			log.Fatalf("Scheduler error: %s", err)
+		}
+	}
+	if cfg.AccessLogRetentionDays > 0 {
+		err = cron.Add("access_log_purge", cfg.AccessLogPurgeCron, func(ctx context.Context, scheduledFor time.Time, _ int) error {
+			_, err := accessLogService.PurgeAccessLogs(ctx, scheduledFor.AddDate(0, 0, -cfg.AccessLogRetentionDays))
+			return err
+		})
+		if err != nil {
+			log.Fatalf("Scheduler error: %s", err)
+		}
+	}
+	cron.Start(context.Background())
 
 	r := gin.Default()
 	//r.Use(config.RequestLoggerMiddleware())
@@ -47,40 +131,96 @@ func main() {
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	v1 := r.Group("/api/v1")
+	v1.Use(accessLogHandler.Middleware())
 
 	v1trans := v1.Group("/transactions")
 	{
 		v1trans.POST("/new", txHandler.CreateTransaction)
 		v1trans.GET("/:id", txHandler.GetTransaction)
+		v1trans.POST("/:id/refund", txHandler.RefundTransaction)
 		v1trans.GET("/bymerchant/:merchantID", txHandler.GetMerchantTransactions)
+		v1trans.GET("/byterminal/:terminalID", terminalHandler.GetTerminalTransactions)
 		v1trans.GET("/transactions", txHandler.GetAllTransactions)
 
 		v1trans.GET("/revenue", txHandler.GetAllRevenue)
 		v1trans.GET("/revenuebymerchant/:merchantID", txHandler.GetAllRevenueByMerchant)
+		v1trans.GET("/summaries/bymerchant/:merchantID", txHandler.GetMerchantDailySummaries)
 	}
 
 	admin := v1.Group("/admin")
 	{
+		admin.GET("/businesses", adminHandler.ListBusinesses)
 		admin.POST("/businesses/new", adminHandler.RegisterBusiness)
 		admin.GET("/businesses/:id", adminHandler.GetBusiness)
+		admin.PATCH("/businesses/:id", adminHandler.UpdateBusinessProfile)
 		admin.PATCH("/businesses/:id/commission", adminHandler.UpdateBusinessCommission)
+		admin.PATCH("/businesses/:id/fiscal", adminHandler.UpdateBusinessFiscalData)
+		admin.PATCH("/businesses/:id/status", adminHandler.ChangeBusinessStatus)
+		admin.PATCH("/businesses/:id/parent", adminHandler.SetBusinessParent)
+		admin.GET("/businesses/:id/descendants", adminHandler.GetBusinessDescendants)
+		admin.POST("/businesses/:id/restore", adminHandler.RestoreBusiness)
+		admin.GET("/businesses/:id/invoice", invoiceHandler.GenerateCommissionInvoice)
+		admin.POST("/businesses/:id/simulate-commission", simulatorHandler.SimulateCommission)
 		admin.DELETE("/businesses/delete/:id", adminHandler.RemoveBusiness)
+		admin.PATCH("/merchants/:id/status", merchantHandler.ChangeMerchantStatus)
+		admin.POST("/merchants/:id/restore", merchantHandler.RestoreMerchant)
+		admin.POST("/merchants/:id/transfer", merchantHandler.TransferMerchant)
+		admin.PATCH("/merchants/:id/commission", merchantHandler.SetCommissionOverride)
+		admin.DELETE("/merchants/:id/commission", merchantHandler.ClearCommissionOverride)
+		admin.POST("/terminals/:id/deactivate", terminalHandler.DeactivateTerminal)
 	}
 
 	audit := v1.Group("/audit")
 	{
-		audit.GET("/", adminHandler.GetAllLogs)
+		audit.GET("/", auditHandler.SearchLogs)
+		audit.GET("/entries/:id", adminHandler.GetLogDetails)
+		audit.GET("/verify", auditHandler.VerifyChain)
+		audit.GET("/anchors", auditHandler.ListAnchors)
+		audit.GET("/archives", auditHandler.ListArchives)
+		audit.GET("/export", auditHandler.ExportBundle)
+		audit.GET("/access", accessLogHandler.ListAccessLogs)
+		audit.GET("/stream", auditHandler.StreamLogs)
+		audit.POST("/anchors", auditHandler.CreateAnchor)
 		audit.GET("/:resource_id", adminHandler.GetAuditTrail)
 
 	}
 	merchants := v1.Group("/merchants")
 	{
 		merchants.POST("/new", merchantHandler.RegisterMerchant)
+		merchants.GET("/mcc", merchantHandler.ListMCCCodes)
 		merchants.GET("/:id", merchantHandler.GetMerchant)
+		merchants.PATCH("/:id", merchantHandler.UpdateMerchantProfile)
+		merchants.GET("/:id/ownership", merchantHandler.GetMerchantOwnership)
 		merchants.GET("/bybusiness/:businessID", merchantHandler.GetBusinessMerchants)
 		merchants.DELETE("/delete/:id", merchantHandler.RemoveMerchant)
 	}
 
+	terminals := v1.Group("/terminals")
+	{
+		terminals.POST("/new", terminalHandler.RegisterTerminal)
+		terminals.GET("/:id", terminalHandler.GetTerminal)
+		terminals.GET("/bymerchant/:merchantID", terminalHandler.GetMerchantTerminals)
+	}
+
+	reports := v1.Group("/reports")
+	{
+		reports.GET("/leaderboard", reportHandler.Leaderboard)
+		reports.GET("/businesses/:id/rollup", reportHandler.BusinessRollup)
+		reports.GET("/runs", reportHandler.ListReportRuns)
+		reports.POST("/runs/:report", reportHandler.RunReport)
+	}
+
+	// a typo in ACCESS_LOG_ROUTES would silently record nothing
+	registered := map[string]bool{}
+	for _, rt := range r.Routes() {
+		registered[rt.Path] = rt.Method == "GET" || registered[rt.Path]
+	}
+	for _, route := range cfg.AccessLogRoutes {
+		if route != "" && !registered[route] {
+			log.Printf("[ACCESS] %s is not a GET route, its reads are not recorded", route)
+		}
+	}
+
 	log.Printf("Starting server on port %s", cfg.AppPort)
 	if err := r.Run(":" + cfg.AppPort); err != nil {
 		log.Fatalf("Server failed to start: %v", err)
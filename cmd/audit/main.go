@@ -0,0 +1,171 @@
+// Command audit checks the tamper-evident chain of the audit log.
+//
+//	audit verify [-anchors anchors.json]
+//	audit anchor
+//	audit anchors
+//	audit archive [-days N]
+//	audit keygen [-out key.pem]
+//	audit verify-bundle [-pubkey key.pem.pub] bundle.zip
+//
+// verify walks the chain and reports the first broken link, checking it
+// against the stored anchors and the ones in -anchors (an earlier export
+// kept outside the system). It exits with status 1 when the chain is broken.
+// anchor checkpoints the current head and anchors prints every anchor as
+// JSON, ready to be stored elsewhere. archive moves the entries older than
+// -days (AUDIT_RETENTION_DAYS by default) to compressed files right away.
+//
+// keygen creates the Ed25519 key signing export bundles (AUDIT_SIGNING_KEY
+// by default) and verify-bundle checks an exported bundle offline, without
+// the database, against the public key handed out by the exporter. It also
+// exits with status 1 when the bundle does not verify.
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	config "github.com/CardenalDex/crudprotec/cmd"
+	"github.com/CardenalDex/crudprotec/internal/adapter/archive"
+	"github.com/CardenalDex/crudprotec/internal/adapter/bundle"
+	"github.com/CardenalDex/crudprotec/internal/adapter/repository"
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/CardenalDex/crudprotec/internal/usecase"
+)
+
+const usage = "usage: %s verify [-anchors anchors.json] | anchor | anchors | archive [-days N] | keygen [-out key.pem] | verify-bundle [-pubkey key.pem.pub] bundle.zip"
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatalf(usage, os.Args[0])
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+
+	// offline commands, no database needed
+	switch os.Args[1] {
+	case "keygen":
+		fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+		keyFile := fs.String("out", cfg.AuditSigningKey, "private key file, the public key goes to <file>.pub")
+		fs.Parse(os.Args[2:])
+
+		pub, err := bundle.GenerateKey(*keyFile)
+		if err != nil {
+			log.Fatalf("keygen failed: %s", err)
+		}
+		log.Printf("signing key written to %s, public key to %s.pub (%s)", *keyFile, *keyFile, base64.StdEncoding.EncodeToString(pub))
+		return
+
+	case "verify-bundle":
+		fs := flag.NewFlagSet("verify-bundle", flag.ExitOnError)
+		pubFile := fs.String("pubkey", "", "PEM public key of the exporter")
+		fs.Parse(os.Args[2:])
+		if fs.NArg() != 1 {
+			log.Fatalf(usage, os.Args[0])
+		}
+
+		var pub ed25519.PublicKey
+		if *pubFile != "" {
+			if pub, err = bundle.LoadPublicKey(*pubFile); err != nil {
+				log.Fatalf("read public key: %s", err)
+			}
+		} else {
+			log.Printf("no -pubkey given, trusting the key inside the bundle: this proves integrity, not origin")
+		}
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			log.Fatalf("open bundle: %s", err)
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			log.Fatalf("open bundle: %s", err)
+		}
+
+		m, err := bundle.Verify(f, info.Size(), pub)
+		if m != nil {
+			out.Encode(m)
+		}
+		if err != nil {
+			log.Printf("bundle invalid: %s", err)
+			os.Exit(1)
+		}
+		log.Printf("bundle valid: %d entries, sequences %d to %d", m.Entries, m.FirstSequence, m.LastSequence)
+		return
+	}
+
+	repo := repository.NewSQLiteRepository(repository.InitInternalDB(cfg.DatabaseDir))
+	service := usecase.NewAuditService(repo, repo, repo, archive.NewStore(cfg.AuditArchiveDir), nil, repo)
+	ctx := context.Background()
+
+	switch os.Args[1] {
+	case "verify":
+		fs := flag.NewFlagSet("verify", flag.ExitOnError)
+		anchorsFile := fs.String("anchors", "", "JSON file with exported anchors")
+		fs.Parse(os.Args[2:])
+
+		var extra []entity.AuditAnchor
+		if *anchorsFile != "" {
+			raw, err := os.ReadFile(*anchorsFile)
+			if err != nil {
+				log.Fatalf("read anchors: %s", err)
+			}
+			if err := json.Unmarshal(raw, &extra); err != nil {
+				log.Fatalf("parse anchors: %s", err)
+			}
+		}
+
+		v, err := service.VerifyChain(ctx, extra)
+		if err != nil {
+			log.Fatalf("verify failed: %s", err)
+		}
+		out.Encode(v)
+		if !v.Valid {
+			log.Printf("chain broken at sequence %d: %s", v.Broken.Sequence, v.Broken.Reason)
+			os.Exit(1)
+		}
+		log.Printf("chain valid: %d entries, %d anchors checked", v.Checked, v.Anchors)
+
+	case "anchor":
+		a, err := service.CreateAnchor(ctx)
+		if err != nil {
+			log.Fatalf("anchor failed: %s", err)
+		}
+		out.Encode(a)
+
+	case "anchors":
+		anchors, err := service.ListAnchors(ctx)
+		if err != nil {
+			log.Fatalf("list anchors: %s", err)
+		}
+		out.Encode(anchors)
+
+	case "archive":
+		fs := flag.NewFlagSet("archive", flag.ExitOnError)
+		days := fs.Int("days", cfg.AuditRetentionDays, "archive entries older than this many days")
+		fs.Parse(os.Args[2:])
+		if *days <= 0 {
+			log.Fatalf("retention must be at least one day")
+		}
+
+		archives, err := service.ArchiveLogs(ctx, time.Now().AddDate(0, 0, -*days))
+		out.Encode(archives)
+		if err != nil {
+			log.Fatalf("archive failed: %s", err)
+		}
+		log.Printf("%d archive files written", len(archives))
+
+	default:
+		log.Fatalf(usage, os.Args[0])
+	}
+}
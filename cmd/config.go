@@ -1,11 +1,51 @@
 package config
 
-import "github.com/ilyakaznacheev/cleanenv"
+import (
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
 
 type Config struct {
 	AppPort     string `env:"APP_PORT" env-default:"8080"`
 	LogLevel    string `env:"LOG_LEVEL" env-default:"info"`
 	DatabaseDir string `env:"DB_DIR" env-default:"/app/data"` // For internal SQLite
+
+	// Invoicing (CFDI Emisor), defaults to the SAT test taxpayer
+	IssuerRFC         string `env:"ISSUER_RFC" env-default:"EKU9003173C9"`
+	IssuerName        string `env:"ISSUER_NAME" env-default:"ESCUELA KEMPER URGATE"`
+	IssuerTaxRegime   string `env:"ISSUER_TAX_REGIME" env-default:"601"`
+	IssuerPostalCode  string `env:"ISSUER_POSTAL_CODE" env-default:"42501"`
+	IssuerCertNumber  string `env:"ISSUER_CERT_NUMBER" env-default:""`
+	InvoiceSeries     string `env:"INVOICE_SERIES" env-default:"COM"`
+	InvoiceProductKey string `env:"INVOICE_PRODUCT_KEY" env-default:"84121500"` // c_ClaveProdServ
+
+	// Scheduled reports, cron expressions (empty disables the report)
+	ReportsOutboxDir             string        `env:"REPORTS_OUTBOX_DIR" env-default:"/app/data/outbox"`
+	ReportDailyRevenueCron       string        `env:"REPORT_DAILY_REVENUE_CRON" env-default:"0 1 * * *"`
+	ReportMerchantStatementsCron string        `env:"REPORT_MERCHANT_STATEMENTS_CRON" env-default:"15 1 * * *"`
+	ReportReconciliationCron     string        `env:"REPORT_RECONCILIATION_CRON" env-default:"30 1 * * *"`
+	SchedulerMaxRetries          int           `env:"SCHEDULER_MAX_RETRIES" env-default:"3"`
+	SchedulerRetryDelay          time.Duration `env:"SCHEDULER_RETRY_DELAY" env-default:"1m"`
+
+	// Audit chain checkpoints, cron expression (empty disables them)
+	AuditAnchorCron string `env:"AUDIT_ANCHOR_CRON" env-default:"0 * * * *"`
+
+	// Audit retention, older entries move to gzip NDJSON files (0 days keeps
+	// everything in the database)
+	AuditRetentionDays int    `env:"AUDIT_RETENTION_DAYS" env-default:"365"`
+	AuditArchiveDir    string `env:"AUDIT_ARCHIVE_DIR" env-default:"/app/data/audit-archive"`
+	AuditArchiveCron   string `env:"AUDIT_ARCHIVE_CRON" env-default:"45 2 * * *"`
+
+	// Ed25519 key signing audit export bundles, created with ./audit keygen
+	// (exports are disabled until it exists)
+	AuditSigningKey string `env:"AUDIT_SIGNING_KEY" env-default:"/app/data/audit-signing.pem"`
+
+	// Read-access log: comma separated route patterns whose GETs are recorded
+	// (empty turns it off), kept AccessLogRetentionDays (0 keeps everything)
+	AccessLogRoutes        []string `env:"ACCESS_LOG_ROUTES" env-separator:"," env-default:"/api/v1/transactions/:id,/api/v1/transactions/bymerchant/:merchantID,/api/v1/transactions/byterminal/:terminalID,/api/v1/transactions/transactions,/api/v1/transactions/revenue,/api/v1/transactions/revenuebymerchant/:merchantID,/api/v1/transactions/summaries/bymerchant/:merchantID,/api/v1/admin/businesses/:id/invoice,/api/v1/reports/businesses/:id/rollup,/api/v1/audit/,/api/v1/audit/entries/:id,/api/v1/audit/:resource_id,/api/v1/audit/export,/api/v1/audit/access,/api/v1/audit/stream"`
+	AccessLogRetentionDays int      `env:"ACCESS_LOG_RETENTION_DAYS" env-default:"90"`
+	AccessLogPurgeCron     string   `env:"ACCESS_LOG_PURGE_CRON" env-default:"15 3 * * *"`
 }
 
 func LoadConfig() (*Config, error) {
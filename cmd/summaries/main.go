@@ -0,0 +1,55 @@
+// Command summaries maintains the merchant daily summary tables.
+//
+//	summaries rebuild [-from YYYY-MM-DD] [-to YYYY-MM-DD]
+//
+// rebuild recomputes every summary row in the range (whole table by default)
+// from the transactions table, use it after backfills or manual fixes.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	config "github.com/CardenalDex/crudprotec/cmd"
+	"github.com/CardenalDex/crudprotec/internal/adapter/repository"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "rebuild" {
+		log.Fatalf("usage: %s rebuild [-from YYYY-MM-DD] [-to YYYY-MM-DD]", os.Args[0])
+	}
+
+	fs := flag.NewFlagSet("rebuild", flag.ExitOnError)
+	fromFlag := fs.String("from", "", "first day to rebuild (inclusive)")
+	toFlag := fs.String("to", "", "last day to rebuild (inclusive)")
+	fs.Parse(os.Args[2:])
+
+	var from, to time.Time
+	var err error
+	if *fromFlag != "" {
+		if from, err = time.Parse("2006-01-02", *fromFlag); err != nil {
+			log.Fatalf("invalid -from: %s", err)
+		}
+	}
+	if *toFlag != "" {
+		if to, err = time.Parse("2006-01-02", *toFlag); err != nil {
+			log.Fatalf("invalid -to: %s", err)
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+
+	repo := repository.NewSQLiteRepository(repository.InitInternalDB(cfg.DatabaseDir))
+	rows, err := repo.RebuildDailySummaries(context.Background(), from, to)
+	if err != nil {
+		log.Fatalf("rebuild failed: %s", err)
+	}
+	log.Printf("rebuilt %d daily summary rows", rows)
+}
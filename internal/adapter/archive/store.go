@@ -0,0 +1,121 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+)
+
+// Store keeps audit archives in dir as gzip compressed NDJSON, one entry per
+// line, each file next to a <file>.sha256 readable by sha256sum -c
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Write stores entries (a contiguous run of the chain) and returns the file
+// name and its checksum. The file is written under a temporary name and
+// renamed once synced.
+func (s *Store) Write(_ context.Context, entries []entity.Log) (string, string, error) {
+	if len(entries) == 0 {
+		return "", "", fmt.Errorf("nothing to archive")
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", "", err
+	}
+	name := fmt.Sprintf("audit-%012d-%012d.ndjson.gz", entries[0].Sequence, entries[len(entries)-1].Sequence)
+
+	tmp, err := os.CreateTemp(s.dir, ".staging-")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	zw := gzip.NewWriter(io.MultiWriter(tmp, h))
+	enc := json.NewEncoder(zw)
+	for _, l := range entries {
+		l.Diff = nil // derived from the snapshots, recomputed on read
+		if err := enc.Encode(l); err != nil {
+			tmp.Close()
+			return "", "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return "", "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", "", fmt.Errorf("sync %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return "", "", err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	path := filepath.Join(s.dir, name)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(path+".sha256", []byte(sum+"  "+name+"\n"), 0644); err != nil {
+		return "", "", err
+	}
+	return name, sum, nil
+}
+
+// Read loads every entry of an archive after checking its checksum
+func (s *Store) Read(_ context.Context, a entity.AuditArchive) ([]entity.Log, error) {
+	f, err := os.Open(filepath.Join(s.dir, a.File))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	zr, err := gzip.NewReader(io.TeeReader(f, h))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", a.File, err)
+	}
+
+	entries := make([]entity.Log, 0, a.Entries)
+	sc := bufio.NewScanner(zr)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var l entity.Log
+		if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", a.File, len(entries)+1, err)
+		}
+		l.Normalize()
+		if l.Before != nil || l.After != nil {
+			l.Diff = entity.Diff(l.Before, l.After)
+		}
+		entries = append(entries, l)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", a.File, err)
+	}
+	// whatever the gzip reader left unread still counts for the checksum
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != a.SHA256 {
+		return nil, fmt.Errorf("archive %s does not match its checksum", a.File)
+	}
+	return entries, nil
+}
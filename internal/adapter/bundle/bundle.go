@@ -0,0 +1,163 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+)
+
+// Files inside a bundle
+const (
+	EntriesFile   = "entries.ndjson"
+	ManifestFile  = "manifest.json"
+	SignatureFile = "manifest.sig" // base64 Ed25519 signature of manifest.json
+)
+
+// Writer packs audit export bundles: a zip holding the entries as NDJSON,
+// oldest first, the manifest and its signature
+type Writer struct {
+	key ed25519.PrivateKey
+}
+
+// NewWriter loads the signing key written by GenerateKey
+func NewWriter(keyFile string) (*Writer, error) {
+	key, err := LoadPrivateKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{key: key}, nil
+}
+
+// WriteBundle completes the manifest with the entries checksum and the
+// public key, signs it and writes the bundle to out
+func (w *Writer) WriteBundle(out io.Writer, m *entity.BundleManifest, entries []entity.Log) error {
+	var nd bytes.Buffer
+	enc := json.NewEncoder(&nd)
+	for _, l := range entries {
+		l.Diff = nil // derived from the snapshots
+		if err := enc.Encode(l); err != nil {
+			return err
+		}
+	}
+	sum := sha256.Sum256(nd.Bytes())
+	m.EntriesSHA256 = hex.EncodeToString(sum[:])
+	m.PublicKey = base64.StdEncoding.EncodeToString(w.key.Public().(ed25519.PublicKey))
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(w.key, manifest))
+
+	zw := zip.NewWriter(out)
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{EntriesFile, nd.Bytes()},
+		{ManifestFile, manifest},
+		{SignatureFile, []byte(sig + "\n")},
+	} {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: m.CreatedAt})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Verify checks a bundle offline: the manifest signature against pub (nil
+// trusts the key embedded in the manifest, which only proves integrity), the
+// entries checksum and count, the hash of every entry and, for contiguous
+// bundles, the links between them
+func Verify(r io.ReaderAt, size int64, pub ed25519.PublicKey) (*entity.BundleManifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		files[f.Name], err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	for _, name := range []string{EntriesFile, ManifestFile, SignatureFile} {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("%s is missing from the bundle", name)
+		}
+	}
+
+	var m entity.BundleManifest
+	if err := json.Unmarshal(files[ManifestFile], &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", ManifestFile, err)
+	}
+	if pub == nil {
+		raw, err := base64.StdEncoding.DecodeString(m.PublicKey)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return &m, fmt.Errorf("manifest carries no valid public key")
+		}
+		pub = raw
+	}
+	sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(files[SignatureFile])))
+	if err != nil {
+		return &m, fmt.Errorf("%s: %w", SignatureFile, err)
+	}
+	if !ed25519.Verify(pub, files[ManifestFile], sig) {
+		return &m, fmt.Errorf("signature does not match the manifest and key")
+	}
+
+	sum := sha256.Sum256(files[EntriesFile])
+	if hex.EncodeToString(sum[:]) != m.EntriesSHA256 {
+		return &m, fmt.Errorf("%s does not match the manifest checksum", EntriesFile)
+	}
+
+	var prev *entity.Log
+	count := 0
+	sc := bufio.NewScanner(bytes.NewReader(files[EntriesFile]))
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var l entity.Log
+		if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+			return &m, fmt.Errorf("%s line %d: %w", EntriesFile, count+1, err)
+		}
+		count++
+		if count == 1 && l.Sequence != m.FirstSequence {
+			return &m, fmt.Errorf("first entry is sequence %d, the manifest says %d", l.Sequence, m.FirstSequence)
+		}
+		if l.ComputeHash() != l.Hash {
+			return &m, fmt.Errorf("entry %d (sequence %d) does not match its hash", count, l.Sequence)
+		}
+		if m.Contiguous && prev != nil && (l.Sequence != prev.Sequence+1 || l.PrevHash != prev.Hash) {
+			return &m, fmt.Errorf("entry %d (sequence %d) does not link to the previous one", count, l.Sequence)
+		}
+		prev = &l
+	}
+	if err := sc.Err(); err != nil {
+		return &m, err
+	}
+	if count != m.Entries {
+		return &m, fmt.Errorf("%d entries, the manifest says %d", count, m.Entries)
+	}
+	if prev != nil && prev.Sequence != m.LastSequence {
+		return &m, fmt.Errorf("last entry is sequence %d, the manifest says %d", prev.Sequence, m.LastSequence)
+	}
+	return &m, nil
+}
@@ -0,0 +1,187 @@
+package cfdi
+
+import (
+	"context"
+	"encoding/xml"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+)
+
+const (
+	namespace      = "http://www.sat.gob.mx/cfd/4"
+	schemaLocation = "http://www.sat.gob.mx/cfd/4 http://www.sat.gob.mx/sitio_internet/cfd/4/cfdv40.xsd"
+)
+
+// --- CFDI 4.0 document ---
+
+type comprobante struct {
+	XMLName           xml.Name   `xml:"cfdi:Comprobante"`
+	XmlnsCfdi         string     `xml:"xmlns:cfdi,attr"`
+	XmlnsXsi          string     `xml:"xmlns:xsi,attr"`
+	SchemaLocation    string     `xml:"xsi:schemaLocation,attr"`
+	Version           string     `xml:"Version,attr"`
+	Serie             string     `xml:"Serie,attr,omitempty"`
+	Folio             string     `xml:"Folio,attr,omitempty"`
+	Fecha             string     `xml:"Fecha,attr"`
+	Sello             string     `xml:"Sello,attr"`
+	FormaPago         string     `xml:"FormaPago,attr,omitempty"`
+	NoCertificado     string     `xml:"NoCertificado,attr"`
+	Certificado       string     `xml:"Certificado,attr"`
+	SubTotal          string     `xml:"SubTotal,attr"`
+	Moneda            string     `xml:"Moneda,attr"`
+	Total             string     `xml:"Total,attr"`
+	TipoDeComprobante string     `xml:"TipoDeComprobante,attr"`
+	Exportacion       string     `xml:"Exportacion,attr"`
+	MetodoPago        string     `xml:"MetodoPago,attr,omitempty"`
+	LugarExpedicion   string     `xml:"LugarExpedicion,attr"`
+	Emisor            emisor     `xml:"cfdi:Emisor"`
+	Receptor          receptor   `xml:"cfdi:Receptor"`
+	Conceptos         []concepto `xml:"cfdi:Conceptos>cfdi:Concepto"`
+	Impuestos         *impuestos `xml:"cfdi:Impuestos,omitempty"`
+}
+
+type emisor struct {
+	Rfc           string `xml:"Rfc,attr"`
+	Nombre        string `xml:"Nombre,attr"`
+	RegimenFiscal string `xml:"RegimenFiscal,attr"`
+}
+
+type receptor struct {
+	Rfc                     string `xml:"Rfc,attr"`
+	Nombre                  string `xml:"Nombre,attr"`
+	DomicilioFiscalReceptor string `xml:"DomicilioFiscalReceptor,attr"`
+	RegimenFiscalReceptor   string `xml:"RegimenFiscalReceptor,attr"`
+	UsoCFDI                 string `xml:"UsoCFDI,attr"`
+}
+
+type concepto struct {
+	ClaveProdServ    string     `xml:"ClaveProdServ,attr"`
+	NoIdentificacion string     `xml:"NoIdentificacion,attr,omitempty"`
+	Cantidad         string     `xml:"Cantidad,attr"`
+	ClaveUnidad      string     `xml:"ClaveUnidad,attr"`
+	Descripcion      string     `xml:"Descripcion,attr"`
+	ValorUnitario    string     `xml:"ValorUnitario,attr"`
+	Importe          string     `xml:"Importe,attr"`
+	ObjetoImp        string     `xml:"ObjetoImp,attr"`
+	Impuestos        *impuestos `xml:"cfdi:Impuestos,omitempty"`
+}
+
+type impuestos struct {
+	TotalImpuestosTrasladados string     `xml:"TotalImpuestosTrasladados,attr,omitempty"`
+	Traslados                 []traslado `xml:"cfdi:Traslados>cfdi:Traslado"`
+}
+
+type traslado struct {
+	Base       string `xml:"Base,attr"`
+	Impuesto   string `xml:"Impuesto,attr"`
+	TipoFactor string `xml:"TipoFactor,attr"`
+	TasaOCuota string `xml:"TasaOCuota,attr"`
+	Importe    string `xml:"Importe,attr"`
+}
+
+// --- Renderer ---
+
+type Renderer struct {
+	schema   *schema
+	location *time.Location
+}
+
+// NewRenderer builds a CFDI renderer that validates every document against
+// the bundled cfdv40.xsd before returning it
+func NewRenderer() (*Renderer, error) {
+	s, err := parseSchema(cfdv40XSD)
+	if err != nil {
+		return nil, err
+	}
+	// CFDI dates are local to the place of issue
+	loc, err := time.LoadLocation("America/Mexico_City")
+	if err != nil {
+		loc = time.Local
+	}
+	return &Renderer{schema: s, location: loc}, nil
+}
+
+func (r *Renderer) Render(inv *entity.Invoice) ([]byte, error) {
+	doc := comprobante{
+		XmlnsCfdi:         namespace,
+		XmlnsXsi:          "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation:    schemaLocation,
+		Version:           "4.0",
+		Serie:             inv.Issuer.Series,
+		Folio:             inv.Folio,
+		Fecha:             inv.IssuedAt.In(r.location).Format("2006-01-02T15:04:05"),
+		FormaPago:         "99", // Por definir
+		NoCertificado:     inv.Issuer.CertNumber,
+		SubTotal:          entity.FormatCents(inv.Subtotal),
+		Moneda:            "MXN",
+		Total:             entity.FormatCents(inv.Total),
+		TipoDeComprobante: "I",
+		Exportacion:       "01",
+		MetodoPago:        "PUE",
+		LugarExpedicion:   inv.Issuer.PostalCode,
+		Emisor: emisor{
+			Rfc:           inv.Issuer.RFC,
+			Nombre:        inv.Issuer.Name,
+			RegimenFiscal: inv.Issuer.TaxRegime,
+		},
+		Receptor: receptor{
+			Rfc:                     inv.Receiver.RFC,
+			Nombre:                  inv.Receiver.LegalName,
+			DomicilioFiscalReceptor: inv.Receiver.PostalCode,
+			RegimenFiscalReceptor:   inv.Receiver.TaxRegime,
+			UsoCFDI:                 "G03", // Gastos en general
+		},
+	}
+
+	for _, c := range inv.Concepts {
+		doc.Conceptos = append(doc.Conceptos, concepto{
+			ClaveProdServ:    inv.Issuer.ProductCode,
+			NoIdentificacion: c.MerchantID.String()[:8],
+			Cantidad:         "1",
+			ClaveUnidad:      "E48", // Unidad de servicio
+			Descripcion:      c.Description,
+			ValorUnitario:    entity.FormatCents(c.Amount),
+			Importe:          entity.FormatCents(c.Amount),
+			ObjetoImp:        "02",
+			Impuestos: &impuestos{
+				Traslados: []traslado{ivaTraslado(c.Amount, c.Tax)},
+			},
+		})
+	}
+	doc.Impuestos = &impuestos{
+		TotalImpuestosTrasladados: entity.FormatCents(inv.Tax),
+		Traslados:                 []traslado{ivaTraslado(inv.Subtotal, inv.Tax)},
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	out = append([]byte(xml.Header), out...)
+
+	if err := r.schema.Validate(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func ivaTraslado(base, tax int64) traslado {
+	return traslado{
+		Base:       entity.FormatCents(base),
+		Impuesto:   "002", // IVA
+		TipoFactor: "Tasa",
+		TasaOCuota: "0.160000",
+		Importe:    entity.FormatCents(tax),
+	}
+}
+
+// --- Stamping ---
+
+// UnstampedStamper leaves the document as rendered. Plug a PAC client in its
+// place (usecase.InvoiceStamper) to obtain the TimbreFiscalDigital.
+type UnstampedStamper struct{}
+
+func (UnstampedStamper) Stamp(_ context.Context, xml []byte) ([]byte, *entity.InvoiceStamp, error) {
+	return xml, nil, nil
+}
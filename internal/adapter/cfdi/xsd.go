@@ -0,0 +1,356 @@
+package cfdi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/xml"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed cfdv40.xsd
+var cfdv40XSD []byte
+
+// The validator understands the subset of XML Schema used by the bundled
+// cfdv40.xsd: nested elements with sequences and occurrence bounds, xs:any,
+// attributes (use/fixed) and simple types restricted by pattern, enumeration,
+// length and minInclusive over xs:string, xs:decimal and xs:dateTime.
+
+type xsdNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []xsdNode  `xml:",any"`
+}
+
+func (n *xsdNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name && a.Name.Space == "" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (n *xsdNode) child(local string) *xsdNode {
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == local {
+			return &n.Children[i]
+		}
+	}
+	return nil
+}
+
+type simpleType struct {
+	base      string
+	patterns  []*regexp.Regexp
+	enums     []string
+	minLength int
+	maxLength int
+	minIncl   *big.Rat
+}
+
+type attrDecl struct {
+	name     string
+	required bool
+	fixed    string
+	typ      *simpleType
+}
+
+type elemDecl struct {
+	name      string
+	minOccurs int
+	maxOccurs int // -1 = unbounded
+	any       bool
+	children  []*elemDecl
+	attrs     []*attrDecl
+}
+
+type schema struct {
+	namespace string
+	root      *elemDecl
+}
+
+func parseSchema(raw []byte) (*schema, error) {
+	var doc xsdNode
+	if err := xml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	named := map[string]*simpleType{}
+	for i := range doc.Children {
+		n := &doc.Children[i]
+		if n.XMLName.Local == "simpleType" {
+			st, err := parseSimpleType(n)
+			if err != nil {
+				return nil, err
+			}
+			named[n.attr("name")] = st
+		}
+	}
+
+	root := doc.child("element")
+	if root == nil {
+		return nil, fmt.Errorf("xsd: no root element")
+	}
+	el, err := parseElement(root, named)
+	if err != nil {
+		return nil, err
+	}
+	return &schema{namespace: doc.attr("targetNamespace"), root: el}, nil
+}
+
+func parseSimpleType(n *xsdNode) (*simpleType, error) {
+	r := n.child("restriction")
+	if r == nil {
+		return nil, fmt.Errorf("xsd: simpleType %q without restriction", n.attr("name"))
+	}
+	st := &simpleType{base: localName(r.attr("base")), maxLength: -1}
+	for i := range r.Children {
+		f := &r.Children[i]
+		v := f.attr("value")
+		switch f.XMLName.Local {
+		case "pattern":
+			re, err := regexp.Compile("^(?:" + v + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("xsd: pattern %q: %w", v, err)
+			}
+			st.patterns = append(st.patterns, re)
+		case "enumeration":
+			st.enums = append(st.enums, v)
+		case "minLength":
+			st.minLength, _ = strconv.Atoi(v)
+		case "maxLength":
+			st.maxLength, _ = strconv.Atoi(v)
+		case "minInclusive":
+			min, ok := new(big.Rat).SetString(v)
+			if !ok {
+				return nil, fmt.Errorf("xsd: minInclusive %q", v)
+			}
+			st.minIncl = min
+		}
+	}
+	return st, nil
+}
+
+func resolveType(n *xsdNode, named map[string]*simpleType) (*simpleType, error) {
+	if inline := n.child("simpleType"); inline != nil {
+		return parseSimpleType(inline)
+	}
+	t := n.attr("type")
+	if t == "" {
+		return &simpleType{base: "string", maxLength: -1}, nil
+	}
+	if st, ok := named[localName(t)]; ok {
+		return st, nil
+	}
+	if strings.HasPrefix(t, "xs:") {
+		return &simpleType{base: localName(t), maxLength: -1}, nil
+	}
+	return nil, fmt.Errorf("xsd: unknown type %q", t)
+}
+
+func parseElement(n *xsdNode, named map[string]*simpleType) (*elemDecl, error) {
+	el := &elemDecl{name: n.attr("name"), minOccurs: 1, maxOccurs: 1}
+	if n.XMLName.Local == "any" {
+		el.any = true
+	}
+	if v := n.attr("minOccurs"); v != "" {
+		el.minOccurs, _ = strconv.Atoi(v)
+	}
+	if v := n.attr("maxOccurs"); v == "unbounded" {
+		el.maxOccurs = -1
+	} else if v != "" {
+		el.maxOccurs, _ = strconv.Atoi(v)
+	}
+
+	ct := n.child("complexType")
+	if ct == nil {
+		return el, nil
+	}
+	if seq := ct.child("sequence"); seq != nil {
+		for i := range seq.Children {
+			c, err := parseElement(&seq.Children[i], named)
+			if err != nil {
+				return nil, err
+			}
+			el.children = append(el.children, c)
+		}
+	}
+	for i := range ct.Children {
+		a := &ct.Children[i]
+		if a.XMLName.Local != "attribute" {
+			continue
+		}
+		typ, err := resolveType(a, named)
+		if err != nil {
+			return nil, err
+		}
+		el.attrs = append(el.attrs, &attrDecl{
+			name:     a.attr("name"),
+			required: a.attr("use") == "required",
+			fixed:    a.attr("fixed"),
+			typ:      typ,
+		})
+	}
+	return el, nil
+}
+
+func localName(qname string) string {
+	if i := strings.IndexByte(qname, ':'); i >= 0 {
+		return qname[i+1:]
+	}
+	return qname
+}
+
+func (st *simpleType) check(v string) error {
+	n := len([]rune(v))
+	if n < st.minLength {
+		return fmt.Errorf("value %q shorter than %d", v, st.minLength)
+	}
+	if st.maxLength >= 0 && n > st.maxLength {
+		return fmt.Errorf("value %q longer than %d", v, st.maxLength)
+	}
+	switch st.base {
+	case "decimal":
+		d, ok := new(big.Rat).SetString(v)
+		if !ok {
+			return fmt.Errorf("value %q is not a decimal", v)
+		}
+		if st.minIncl != nil && d.Cmp(st.minIncl) < 0 {
+			return fmt.Errorf("value %q below minimum %s", v, st.minIncl.FloatString(6))
+		}
+	case "dateTime":
+		if _, err := time.Parse("2006-01-02T15:04:05", v); err != nil {
+			return fmt.Errorf("value %q is not a dateTime", v)
+		}
+	}
+	for _, re := range st.patterns {
+		if !re.MatchString(v) {
+			return fmt.Errorf("value %q does not match pattern %s", v, re.String())
+		}
+	}
+	if len(st.enums) > 0 {
+		for _, e := range st.enums {
+			if v == e {
+				return nil
+			}
+		}
+		return fmt.Errorf("value %q not in enumeration %v", v, st.enums)
+	}
+	return nil
+}
+
+// docNode is an element of the instance document
+type docNode struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*docNode
+}
+
+func parseDocument(raw []byte) (*docNode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(raw))
+	var stack []*docNode
+	var root *docNode
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if root != nil && len(stack) == 0 {
+				return root, nil
+			}
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &docNode{name: t.Name, attrs: t.Attr}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("multiple root elements")
+				}
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// Validate checks an XML document against the schema
+func (s *schema) Validate(raw []byte) error {
+	doc, err := parseDocument(raw)
+	if err != nil {
+		return fmt.Errorf("xsd: malformed document: %w", err)
+	}
+	return s.validateElement(doc, s.root, "/"+s.root.name)
+}
+
+func (s *schema) validateElement(n *docNode, decl *elemDecl, path string) error {
+	if n.name.Local != decl.name || n.name.Space != s.namespace {
+		return fmt.Errorf("xsd: %s: unexpected element {%s}%s", path, n.name.Space, n.name.Local)
+	}
+
+	seen := map[string]bool{}
+	for _, a := range n.attrs {
+		// namespace declarations and foreign attributes (xsi:*) are not ours
+		if a.Name.Space != "" || a.Name.Local == "xmlns" {
+			continue
+		}
+		seen[a.Name.Local] = true
+		ad := findAttr(decl, a.Name.Local)
+		if ad == nil {
+			return fmt.Errorf("xsd: %s: attribute %s is not allowed", path, a.Name.Local)
+		}
+		if ad.fixed != "" && a.Value != ad.fixed {
+			return fmt.Errorf("xsd: %s@%s: must be %q", path, ad.name, ad.fixed)
+		}
+		if err := ad.typ.check(a.Value); err != nil {
+			return fmt.Errorf("xsd: %s@%s: %w", path, ad.name, err)
+		}
+	}
+	for _, ad := range decl.attrs {
+		if ad.required && !seen[ad.name] {
+			return fmt.Errorf("xsd: %s: missing required attribute %s", path, ad.name)
+		}
+	}
+
+	i := 0
+	for _, cd := range decl.children {
+		count := 0
+		for i < len(n.children) && (cd.any || n.children[i].name.Local == cd.name) {
+			if cd.maxOccurs >= 0 && count == cd.maxOccurs {
+				break
+			}
+			if !cd.any {
+				childPath := fmt.Sprintf("%s/%s[%d]", path, cd.name, count+1)
+				if err := s.validateElement(n.children[i], cd, childPath); err != nil {
+					return err
+				}
+			}
+			count++
+			i++
+		}
+		if count < cd.minOccurs {
+			return fmt.Errorf("xsd: %s: expected element %s", path, cd.name)
+		}
+	}
+	if i < len(n.children) {
+		return fmt.Errorf("xsd: %s: unexpected element %s", path, n.children[i].name.Local)
+	}
+	return nil
+}
+
+func findAttr(decl *elemDecl, name string) *attrDecl {
+	for _, a := range decl.attrs {
+		if a.name == name {
+			return a
+		}
+	}
+	return nil
+}
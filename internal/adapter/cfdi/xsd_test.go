@@ -0,0 +1,160 @@
+package cfdi
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/google/uuid"
+)
+
+func testInvoice() *entity.Invoice {
+	return &entity.Invoice{
+		BusinessID: uuid.New(),
+		Folio:      "20260101-0a1b2c3d-20260201120000000",
+		IssuedAt:   time.Date(2026, 2, 1, 18, 0, 0, 0, time.UTC),
+		Issuer: entity.InvoiceIssuer{
+			RFC:         "EKU9003173C9",
+			Name:        "ESCUELA KEMPER URGATE",
+			TaxRegime:   "601",
+			PostalCode:  "06000",
+			Series:      "COM",
+			ProductCode: "84141600",
+		},
+		Receiver: entity.Business{BusinessProfile: entity.BusinessProfile{FiscalData: entity.FiscalData{
+			LegalName:  "XIAOMI TECHNOLOGY MEXICO",
+			RFC:        "XIA190128J61",
+			TaxRegime:  "601",
+			PostalCode: "01219",
+		}}},
+		Concepts: []entity.InvoiceConcept{
+			{MerchantID: uuid.New(), Description: "Comisiones Tienda Centro", TransactionCount: 3, Amount: 1000, Tax: 160},
+		},
+		Subtotal: 1000,
+		Tax:      160,
+		Total:    1160,
+	}
+}
+
+func TestRenderValidatesAgainstBundledXSD(t *testing.T) {
+	r, err := NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	out, err := r.Render(testInvoice())
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	for _, want := range []string{`Version="4.0"`, `Total="11.60"`, `Rfc="XIA190128J61"`, `Fecha="2026-02-01T12:00:00"`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("rendered document lacks %s", want)
+		}
+	}
+}
+
+func TestRenderRejectsNegativeAmounts(t *testing.T) {
+	r, err := NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	inv := testInvoice()
+	inv.Concepts[0].Amount, inv.Subtotal, inv.Total = -1000, -1000, -840
+	if _, err := r.Render(inv); err == nil || !strings.Contains(err.Error(), "below minimum") {
+		t.Fatalf("Render = %v, want a minInclusive error", err)
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	r, err := NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	valid, err := r.Render(testInvoice())
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		old, new string // replaced everywhere in the valid document
+		wantErr  string // empty when the edited document is still valid
+	}{
+		{"unchanged", "", "", ""},
+		{"foreign element in Complemento position", "</cfdi:Comprobante>", "<cfdi:Complemento><x:Timbre xmlns:x=\"urn:x\"/></cfdi:Complemento></cfdi:Comprobante>", ""},
+		{"fixed attribute", `Version="4.0"`, `Version="3.3"`, `must be "4.0"`},
+		{"missing required attribute", ` Moneda="MXN"`, "", "missing required attribute Moneda"},
+		{"unknown attribute", `Moneda="MXN"`, `Moneda="MXN" Color="red"`, "attribute Color is not allowed"},
+		{"pattern", `Rfc="XIA190128J61"`, `Rfc="xia190128j61"`, "does not match pattern"},
+		{"enumeration", `UsoCFDI="G03"`, `UsoCFDI="D01"`, "not in enumeration"},
+		{"below minInclusive", `Total="11.60"`, `Total="-11.60"`, "below minimum"},
+		{"not a decimal", `Total="11.60"`, `Total="once"`, "is not a decimal"},
+		{"not a dateTime", `Fecha="2026-02-01T12:00:00"`, `Fecha="2026-02-01"`, "is not a dateTime"},
+		{"missing element", "cfdi:Emisor", "cfdi:Otro", "expected element Emisor"},
+		{"wrong namespace", `xmlns:cfdi="http://www.sat.gob.mx/cfd/4"`, `xmlns:cfdi="http://www.sat.gob.mx/cfd/3"`, "unexpected element"},
+		{"malformed", "</cfdi:Comprobante>", "", "malformed document"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := string(valid)
+			if tt.old != "" {
+				if !strings.Contains(doc, tt.old) {
+					t.Fatalf("document lacks %q", tt.old)
+				}
+				doc = strings.ReplaceAll(doc, tt.old, tt.new)
+			}
+			err := r.schema.Validate([]byte(doc))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate = %v, want valid", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchemaOccurrences(t *testing.T) {
+	s, err := parseSchema([]byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:t">
+  <xs:element name="Root">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="One"/>
+        <xs:element name="Some" minOccurs="0" maxOccurs="2"/>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`))
+	if err != nil {
+		t.Fatalf("parseSchema: %v", err)
+	}
+
+	tests := []struct {
+		body    string
+		wantErr string
+	}{
+		{"<One/>", ""},
+		{"<One/><Some/><Some/>", ""},
+		{"", "expected element One"},
+		{"<One/><One/>", "unexpected element One"},
+		{"<One/><Some/><Some/><Some/>", "unexpected element Some"},
+		{"<Some/><One/>", "expected element One"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			err := s.Validate([]byte(`<Root xmlns="urn:t">` + tt.body + `</Root>`))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate = %v, want valid", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/CardenalDex/crudprotec/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+type AccessLogHandler struct {
+	service usecase.AccessLogUseCase
+	routes  map[string]bool
+}
+
+// NewAccessLogHandler records the reads of the given route patterns (as
+// registered, e.g. /api/v1/transactions/:id), other routes are left alone
+func NewAccessLogHandler(s usecase.AccessLogUseCase, routes []string) *AccessLogHandler {
+	h := &AccessLogHandler{service: s, routes: map[string]bool{}}
+	for _, r := range routes {
+		if r != "" {
+			h.routes[r] = true
+		}
+	}
+	return h
+}
+
+// Middleware records who read a configured route once the handler answered.
+// A failed record is logged, the response is already on its way.
+func (h *AccessLogHandler) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if c.Request.Method != http.MethodGet || !h.routes[route] {
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		a := &entity.AccessLog{
+			Actor:  c.GetHeader("actor"),
+			Method: c.Request.Method,
+			Route:  route,
+			Path:   c.Request.URL.Path,
+			Query:  c.Request.URL.Query(),
+			Status: c.Writer.Status(),
+		}
+		if len(c.Params) > 0 {
+			a.ResourceID = c.Params[0].Value
+		}
+		// a client hanging up must not lose the record
+		if err := h.service.RecordAccess(context.WithoutCancel(c.Request.Context()), a); err != nil {
+			log.Printf("[ACCESS] failed to record %s %s: %s", a.Method, a.Path, err)
+		}
+	}
+}
+
+// @Summary Search the Access Log
+// @Description Who read sensitive data (transactions, balances, statements, audit logs), newest first. Only the routes in ACCESS_LOG_ROUTES are recorded, records older than ACCESS_LOG_RETENTION_DAYS are dropped.
+// @Tags audit
+// @Produce json
+// @Param actor query string false "Actor header of the read"
+// @Param resource_id query string false "First path parameter of the route, e.g. the merchant ID"
+// @Param route query string false "Route pattern, e.g. /api/v1/transactions/bymerchant/:merchantID"
+// @Param from query string false "On or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Before (RFC3339), or on or before (YYYY-MM-DD)"
+// @Param limit query int false "Max records (default 100, max 500)"
+// @Success 200 {array} entity.AccessLog
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /audit/access [get]
+func (h *AccessLogHandler) ListAccessLogs(c *gin.Context) {
+	filter := entity.AccessLogFilter{
+		Actor:      c.Query("actor"),
+		ResourceID: c.Query("resource_id"),
+		Route:      c.Query("route"),
+	}
+	var err error
+
+	if filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "100")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+	if filter.From, err = auditTime(c.Query("from"), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from', expected RFC3339 or YYYY-MM-DD"})
+		return
+	}
+	if filter.To, err = auditTime(c.Query("to"), true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to', expected RFC3339 or YYYY-MM-DD"})
+		return
+	}
+
+	logs, err := h.service.ListAccessLogs(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
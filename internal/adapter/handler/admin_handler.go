@@ -2,8 +2,11 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
-	_ "github.com/CardenalDex/crudprotec/internal/entitys" // neeeded for swagger
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
 	"github.com/CardenalDex/crudprotec/internal/usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -11,14 +14,135 @@ import (
 
 type AdminHandler struct {
 	service usecase.AdminUseCase
+	audit   usecase.AuditUseCase // as_of reads
 }
 
-func NewAdminHandler(s usecase.AdminUseCase) *AdminHandler {
-	return &AdminHandler{service: s}
+func NewAdminHandler(s usecase.AdminUseCase, a usecase.AuditUseCase) *AdminHandler {
+	return &AdminHandler{service: s, audit: a}
+}
+
+type fiscalDataRequest struct {
+	LegalName  string `json:"legal_name"`
+	RFC        string `json:"rfc"`
+	TaxRegime  string `json:"tax_regime"`  // SAT c_RegimenFiscal, e.g. "601"
+	PostalCode string `json:"postal_code"` // fiscal address
+}
+
+func (f fiscalDataRequest) toEntity() entity.FiscalData {
+	return entity.FiscalData{
+		LegalName:  strings.TrimSpace(f.LegalName),
+		RFC:        strings.ToUpper(strings.TrimSpace(f.RFC)),
+		TaxRegime:  strings.TrimSpace(f.TaxRegime),
+		PostalCode: strings.TrimSpace(f.PostalCode),
+	}
+}
+
+type addressRequest struct {
+	Street         string `json:"street"`
+	ExteriorNumber string `json:"exterior_number"`
+	InteriorNumber string `json:"interior_number"`
+	Neighborhood   string `json:"neighborhood"`
+	City           string `json:"city"`
+	State          string `json:"state"`
+	PostalCode     string `json:"postal_code"`
+	Country        string `json:"country"` // ISO 3166-1 alpha-2, e.g. "MX"
+}
+
+func (a addressRequest) toEntity() entity.Address {
+	return entity.Address{
+		Street:         strings.TrimSpace(a.Street),
+		ExteriorNumber: strings.TrimSpace(a.ExteriorNumber),
+		InteriorNumber: strings.TrimSpace(a.InteriorNumber),
+		Neighborhood:   strings.TrimSpace(a.Neighborhood),
+		City:           strings.TrimSpace(a.City),
+		State:          strings.TrimSpace(a.State),
+		PostalCode:     strings.TrimSpace(a.PostalCode),
+		Country:        strings.ToUpper(strings.TrimSpace(a.Country)),
+	}
 }
 
 type createBusinessRequest struct {
-	Commission float64 `json:"commission_percentage" binding:"required,gt=0"` // e.g., 5.5 for 5.5%
+	Commission float64 `json:"commission_percentage" binding:"omitempty,gt=0"` // e.g., 5.5 for 5.5%, omitted inherits the parent's
+	ParentID   string  `json:"parent_id"`
+	fiscalDataRequest
+	TradeName    string         `json:"trade_name"`
+	ContactEmail string         `json:"contact_email"`
+	Phone        string         `json:"phone"`
+	Address      addressRequest `json:"address"`
+}
+
+// updateBusinessProfileRequest: omitted fields are left unchanged
+type updateBusinessProfileRequest struct {
+	LegalName    *string         `json:"legal_name"`
+	TradeName    *string         `json:"trade_name"`
+	RFC          *string         `json:"rfc"`
+	TaxRegime    *string         `json:"tax_regime"`
+	PostalCode   *string         `json:"postal_code"`
+	ContactEmail *string         `json:"contact_email"`
+	Phone        *string         `json:"phone"`
+	Address      *addressRequest `json:"address"`
+}
+
+func (r updateBusinessProfileRequest) toEntity() entity.BusinessProfilePatch {
+	patch := entity.BusinessProfilePatch{
+		LegalName:    trimField(r.LegalName, nil),
+		TradeName:    trimField(r.TradeName, nil),
+		RFC:          trimField(r.RFC, strings.ToUpper),
+		TaxRegime:    trimField(r.TaxRegime, nil),
+		PostalCode:   trimField(r.PostalCode, nil),
+		ContactEmail: trimField(r.ContactEmail, nil),
+		Phone:        trimField(r.Phone, normalizePhone),
+	}
+	if r.Address != nil {
+		addr := r.Address.toEntity()
+		patch.Address = &addr
+	}
+	return patch
+}
+
+// includeDeleted reads the include_deleted query flag
+func includeDeleted(c *gin.Context) bool {
+	v, _ := strconv.ParseBool(c.Query("include_deleted"))
+	return v
+}
+
+// diffOnly drops the snapshots when ?diff_only=true, reviewers mostly want
+// the changed fields
+func diffOnly(c *gin.Context, logs []entity.Log) []entity.Log {
+	if v, _ := strconv.ParseBool(c.Query("diff_only")); !v {
+		return logs
+	}
+	for i := range logs {
+		logs[i].Before, logs[i].After = nil, nil
+	}
+	return logs
+}
+
+// trimField trims an optional patch field and applies normalize when given,
+// nil stays nil (field not sent)
+func trimField(v *string, normalize func(string) string) *string {
+	if v == nil {
+		return nil
+	}
+	out := strings.TrimSpace(*v)
+	if normalize != nil {
+		out = normalize(out)
+	}
+	return &out
+}
+
+// normalizePhone drops the usual separators: "+52 (55) 1234-5678" -> "+525512345678"
+func normalizePhone(p string) string {
+	return strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "").Replace(p)
+}
+
+// setParentRequest: a null or empty parent_id detaches the business
+type setParentRequest struct {
+	ParentID *string `json:"parent_id"`
+}
+
+type restoreRequest struct {
+	Reason string `json:"reason" binding:"required"`
 }
 
 type updateCommissionRequest struct {
@@ -48,9 +172,27 @@ func (h *AdminHandler) RegisterBusiness(c *gin.Context) {
 	// Convert Percentage (5.5) -> Basis Points (550)
 	commissionBP := int64(req.Commission * 100)
 
-	biz, err := h.service.RegisterBusiness(c.Request.Context(), actor, commissionBP)
+	var parentID *uuid.UUID
+	if req.ParentID != "" {
+		p, err := uuid.Parse(req.ParentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent_id"})
+			return
+		}
+		parentID = &p
+	}
+
+	profile := entity.BusinessProfile{
+		FiscalData:   req.fiscalDataRequest.toEntity(),
+		TradeName:    strings.TrimSpace(req.TradeName),
+		ContactEmail: strings.TrimSpace(req.ContactEmail),
+		Phone:        normalizePhone(strings.TrimSpace(req.Phone)),
+		Address:      req.Address.toEntity(),
+	}
+
+	biz, err := h.service.RegisterBusiness(c.Request.Context(), actor, commissionBP, profile, parentID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -58,13 +200,16 @@ func (h *AdminHandler) RegisterBusiness(c *gin.Context) {
 }
 
 // @Summary Get Business Details
-// @Description Retrieve details of a specific business by ID
+// @Description Retrieve details of a specific business by ID. With as_of the business is rebuilt from the audit snapshots as it was at that time and returned with the audit entry it comes from.
 // @Tags admin
 // @Produce json
 // @Param id path string true "Business UUID"
-// @Success 200 {object} entity.Business
+// @Param include_deleted query bool false "Also return soft deleted records"
+// @Param as_of query string false "Point in time (RFC3339), a plain YYYY-MM-DD means the end of that day"
+// @Success 200 {object} entity.Business "entity.HistoricalBusiness with as_of"
 // @Failure 400 {object} map[string]string "Invalid UUID"
 // @Failure 404 {object} map[string]string "Business not found"
+// @Failure 422 {object} map[string]string "HISTORY_UNAVAILABLE, the audit entry predates the snapshots"
 // @Router /admin/businesses/{id} [get]
 func (h *AdminHandler) GetBusiness(c *gin.Context) {
 	idParam := c.Param("id")
@@ -74,7 +219,22 @@ func (h *AdminHandler) GetBusiness(c *gin.Context) {
 		return
 	}
 
-	biz, err := h.service.GetBusiness(c.Request.Context(), id)
+	if v := c.Query("as_of"); v != "" {
+		at, err := asOfTime(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'as_of', expected RFC3339 or YYYY-MM-DD"})
+			return
+		}
+		hist, err := h.audit.GetBusinessAsOf(c.Request.Context(), id, at)
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusNotFound), errorBody(err))
+			return
+		}
+		c.JSON(http.StatusOK, hist)
+		return
+	}
+
+	biz, err := h.service.GetBusiness(c.Request.Context(), id, includeDeleted(c))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -83,6 +243,89 @@ func (h *AdminHandler) GetBusiness(c *gin.Context) {
 	c.JSON(http.StatusOK, biz)
 }
 
+// @Summary List Businesses
+// @Description Paginated business search with commission, creation date and deletion filters, including the number of active merchants of each business
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Param sort query string false "created_at (default), updated_at, commission, legal_name or merchant_count"
+// @Param order query string false "asc (default) or desc"
+// @Param min_commission query number false "Minimum commission percentage (inclusive)"
+// @Param max_commission query number false "Maximum commission percentage (inclusive)"
+// @Param created_from query string false "Created on or after (YYYY-MM-DD)"
+// @Param created_to query string false "Created on or before (YYYY-MM-DD)"
+// @Param status query string false "active (default), deleted or all"
+// @Param include_deleted query bool false "Shorthand for status=all"
+// @Success 200 {object} entity.BusinessPage
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/businesses [get]
+func (h *AdminHandler) ListBusinesses(c *gin.Context) {
+	var filter entity.BusinessFilter
+	var err error
+
+	if filter.Page, err = strconv.Atoi(c.DefaultQuery("page", "1")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+		return
+	}
+	if filter.PageSize, err = strconv.Atoi(c.DefaultQuery("page_size", "20")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page_size"})
+		return
+	}
+	filter.SortBy = c.DefaultQuery("sort", "created_at")
+	filter.Desc = c.Query("order") == "desc"
+
+	// Convert Percentage -> Basis Points
+	for param, dst := range map[string]**int64{"min_commission": &filter.MinCommission, "max_commission": &filter.MaxCommission} {
+		if v := c.Query(param); v != "" {
+			pct, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+				return
+			}
+			bp := int64(pct * 100)
+			*dst = &bp
+		}
+	}
+	if v := c.Query("created_from"); v != "" {
+		from, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid created_from, expected YYYY-MM-DD"})
+			return
+		}
+		filter.CreatedFrom = &from
+	}
+	if v := c.Query("created_to"); v != "" {
+		to, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid created_to, expected YYYY-MM-DD"})
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+		filter.CreatedTo = &to
+	}
+	defaultStatus := entity.OnlyActive
+	if includeDeleted(c) {
+		defaultStatus = entity.AnyDeletion
+	}
+	switch status := entity.DeletionFilter(c.DefaultQuery("status", string(defaultStatus))); status {
+	case entity.OnlyActive, entity.OnlyDeleted, entity.AnyDeletion:
+		filter.Deletion = status
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active, deleted or all"})
+		return
+	}
+
+	page, err := h.service.ListBusinesses(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
 // @Summary Update Business Commission
 // @Description Update the commission rate for an existing business
 // @Tags admin
@@ -121,14 +364,124 @@ func (h *AdminHandler) UpdateBusinessCommission(c *gin.Context) {
 	c.JSON(http.StatusOK, biz)
 }
 
+// @Summary Update Business Fiscal Data
+// @Description Set the fiscal data (legal name, RFC, tax regime, postal code) used to invoice the business
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param actor header string false "The name of the user performing the action"
+// @Param id path string true "Business UUID"
+// @Param fiscal body fiscalDataRequest true "Fiscal Data"
+// @Success 200 {object} entity.Business
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/businesses/{id}/fiscal [patch]
+func (h *AdminHandler) UpdateBusinessFiscalData(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	actor := c.GetHeader("actor")
+	var req fiscalDataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	biz, err := h.service.UpdateBusinessFiscalData(c.Request.Context(), actor, id, req.toEntity())
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, biz)
+}
+
+// @Summary Update Business Profile
+// @Description Partial update of the business profile (legal and trade name, RFC, tax regime, contact data and address). Omitted fields are left unchanged, each changed field is audited.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param actor header string false "The name of the user performing the action"
+// @Param id path string true "Business UUID"
+// @Param profile body updateBusinessProfileRequest true "Profile fields to change"
+// @Success 200 {object} entity.Business
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/businesses/{id} [patch]
+func (h *AdminHandler) UpdateBusinessProfile(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	actor := c.GetHeader("actor")
+	var req updateBusinessProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	biz, err := h.service.UpdateBusinessProfile(c.Request.Context(), actor, id, req.toEntity())
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, biz)
+}
+
+// @Summary Change Business Status
+// @Description Suspend or reactivate a business. Merchants of a suspended business cannot process transactions.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param actor header string false "The name of the user performing the action"
+// @Param id path string true "Business UUID"
+// @Param status body changeStatusRequest true "Target status (active or suspended) and reason"
+// @Success 200 {object} entity.Business
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 409 {object} map[string]string "Already in that status (code INVALID_STATUS_TRANSITION)"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/businesses/{id}/status [patch]
+func (h *AdminHandler) ChangeBusinessStatus(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	actor := c.GetHeader("actor")
+	var req changeStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := entity.BusinessStatus(strings.ToLower(strings.TrimSpace(req.Status)))
+	biz, err := h.service.ChangeBusinessStatus(c.Request.Context(), actor, id, status, strings.TrimSpace(req.Reason))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, biz)
+}
+
 // @Summary Delete a Business
-// @Description Soft delete a business (Logical Delete)
+// @Description Soft delete a business (Logical Delete). The policy decides what happens to its merchants: block (default) refuses while merchants exist, cascade soft deletes them, reassign moves them to target_business_id. Everything happens in one transaction.
 // @Tags admin
 // @Produce json
 // @Param actor header string false "The name of the user performing the action"
 // @Param id path string true "Business UUID"
-// @Success 200 {object} map[string]string "Success message"
-// @Failure 400 {object} map[string]string "Invalid UUID"
+// @Param policy query string false "block (default), cascade or reassign"
+// @Param target_business_id query string false "Business receiving the merchants (reassign only)"
+// @Success 200 {object} map[string]interface{} "Success message and affected merchants"
+// @Failure 400 {object} map[string]string "Invalid UUID or policy"
+// @Failure 409 {object} map[string]string "Business still has merchants (code BUSINESS_HAS_MERCHANTS)"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /admin/businesses/delete/{id} [delete]
 func (h *AdminHandler) RemoveBusiness(c *gin.Context) {
@@ -139,47 +492,184 @@ func (h *AdminHandler) RemoveBusiness(c *gin.Context) {
 		return
 	}
 	actor := c.GetHeader("actor")
-	if err := h.service.RemoveBusiness(c.Request.Context(), actor, id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+	var target *uuid.UUID
+	if v := c.Query("target_business_id"); v != "" {
+		t, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target_business_id"})
+			return
+		}
+		target = &t
+	}
+	policy := entity.DeletionPolicy(c.DefaultQuery("policy", string(entity.DeleteBlock)))
+
+	d, err := h.service.RemoveBusiness(c.Request.Context(), actor, id, policy, target)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Business deleted successfully",
+		"policy":             d.Policy,
+		"target_business_id": d.TargetBusinessID,
+		"merchants":          d.Merchants,
+	})
+}
+
+// @Summary Restore a Business
+// @Description Undo the soft delete of a business. Merchants deleted by a cascade are restored separately.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param actor header string false "The name of the user performing the action"
+// @Param id path string true "Business UUID"
+// @Param restore body restoreRequest true "Reason of the restore"
+// @Success 200 {object} entity.Business
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 409 {object} map[string]string "Business is not deleted (code NOT_DELETED)"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/businesses/{id}/restore [post]
+func (h *AdminHandler) RestoreBusiness(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	actor := c.GetHeader("actor")
+	var req restoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	biz, err := h.service.RestoreBusiness(c.Request.Context(), actor, id, strings.TrimSpace(req.Reason))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Business deleted successfully"})
+	c.JSON(http.StatusOK, biz)
+}
+
+// @Summary Set the Parent of a Business
+// @Description Move a business, with its whole subtree, under another business of the group. A null parent_id makes it a top level business.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param actor header string false "The name of the user performing the action"
+// @Param id path string true "Business UUID"
+// @Param parent body setParentRequest true "New parent"
+// @Success 200 {object} entity.Business
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 409 {object} map[string]string "Parent is inside the subtree (code HIERARCHY_CYCLE)"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/businesses/{id}/parent [patch]
+func (h *AdminHandler) SetBusinessParent(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	actor := c.GetHeader("actor")
+	var req setParentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var parentID *uuid.UUID
+	if req.ParentID != nil && *req.ParentID != "" {
+		p, err := uuid.Parse(*req.ParentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent_id"})
+			return
+		}
+		parentID = &p
+	}
+
+	biz, err := h.service.SetBusinessParent(c.Request.Context(), actor, id, parentID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, biz)
+}
+
+// @Summary List Descendants of a Business
+// @Description Every live business below the given one, with its depth (1 = direct child) and number of active merchants
+// @Tags admin
+// @Produce json
+// @Param id path string true "Business UUID"
+// @Success 200 {array} entity.BusinessNode
+// @Failure 400 {object} map[string]string "Invalid UUID"
+// @Failure 404 {object} map[string]string "Business not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/businesses/{id}/descendants [get]
+func (h *AdminHandler) GetBusinessDescendants(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	nodes, err := h.service.GetBusinessDescendants(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, nodes)
 }
 
 // @Summary Get Audit Logs
-// @Description Retrieve audit logs for a specific resource (e.g., a Business ID or Transaction ID)
+// @Description Retrieve audit logs for a specific resource (e.g., a Business ID or Transaction ID), archived entries included. Each entry carries the resource snapshots Before and After the change and the field-level Diff between them.
 // @Tags audit
 // @Produce json
 // @Param resource_id path string true "Resource ID"
+// @Param diff_only query bool false "Leave the Before/After snapshots out, keeping only the Diff"
 // @Success 200 {array} entity.Log
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /audit/{resource_id} [get]
 func (h *AdminHandler) GetAuditTrail(c *gin.Context) {
 	resourceID := c.Param("resource_id")
 
-	logs, err := h.service.GetAuditTrail(c.Request.Context(), resourceID)
+	logs, err := h.audit.GetAuditTrail(c.Request.Context(), resourceID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, logs)
+	c.JSON(http.StatusOK, diffOnly(c, logs))
 }
 
-// @Summary Get All Logs
-// @Description Retrieve all system audit logs
+// @Summary Get Audit Entry
+// @Description Retrieve a single audit log entry by its ID, archived entries included
 // @Tags audit
 // @Produce json
-// @Success 200 {array} entity.Log
-// @Failure 500 {object} map[string]string "Internal Server Error"
-// @Router /audit [get]
-func (h *AdminHandler) GetAllLogs(c *gin.Context) {
-	logs, err := h.service.GetAllLogs(c.Request.Context())
+// @Param id path string true "Log entry UUID"
+// @Param diff_only query bool false "Leave the Before/After snapshots out, keeping only the Diff"
+// @Success 200 {object} entity.Log
+// @Failure 400 {object} map[string]string "Invalid UUID"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Router /audit/entries/{id} [get]
+func (h *AdminHandler) GetLogDetails(c *gin.Context) {
+	idParam := c.Param("id")
+	if _, err := uuid.Parse(idParam); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	l, err := h.audit.GetLogDetails(c.Request.Context(), idParam)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, logs)
+	c.JSON(http.StatusOK, diffOnly(c, []entity.Log{l})[0])
 }
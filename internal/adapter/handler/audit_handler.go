@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/CardenalDex/crudprotec/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	service usecase.AuditUseCase
+}
+
+func NewAuditHandler(s usecase.AuditUseCase) *AuditHandler {
+	return &AuditHandler{service: s}
+}
+
+// @Summary Verify the Audit Chain
+// @Description Walks the audit log hash chain and reports the first broken link (missing, modified or reordered entry), also checking every stored anchor
+// @Tags audit
+// @Produce json
+// @Success 200 {object} entity.ChainVerification
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /audit/verify [get]
+func (h *AuditHandler) VerifyChain(c *gin.Context) {
+	v, err := h.service.VerifyChain(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, v)
+}
+
+// @Summary Anchor the Audit Chain
+// @Description Checkpoints the current head of the chain (sequence and hash). Anchors are also created on the AUDIT_ANCHOR_CRON schedule.
+// @Tags audit
+// @Produce json
+// @Success 201 {object} entity.AuditAnchor
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /audit/anchors [post]
+func (h *AuditHandler) CreateAnchor(c *gin.Context) {
+	a, err := h.service.CreateAnchor(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, a)
+}
+
+// @Summary Export Audit Anchors
+// @Description Lists the anchors, oldest first. Store the export outside the system and give it back to the audit verify command to detect a rewritten chain.
+// @Tags audit
+// @Produce json
+// @Param download query bool false "Serve the list as a file attachment"
+// @Success 200 {array} entity.AuditAnchor
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /audit/anchors [get]
+func (h *AuditHandler) ListAnchors(c *gin.Context) {
+	anchors, err := h.service.ListAnchors(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if v, _ := strconv.ParseBool(c.Query("download")); v {
+		c.Header("Content-Disposition", `attachment; filename="audit-anchors-`+time.Now().UTC().Format("20060102T150405Z")+`.json"`)
+	}
+	c.JSON(http.StatusOK, anchors)
+}
+
+// @Summary Search Audit Logs
+// @Description Audit search, newest entries first, archived entries included when the range reaches into them, with their Before/After snapshots and Diff. Pass NextCursor back as cursor to read the next page.
+// @Tags audit
+// @Produce json
+// @Param actor query string false "Actor header of the change"
+// @Param action query string false "Action, e.g. UPDATE_BUSINESS_COMMISSION"
+// @Param resource_id query string false "ID of the affected resource"
+// @Param resource_type query string false "business, merchant, transaction or terminal"
+// @Param from query string false "On or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Before (RFC3339), or on or before (YYYY-MM-DD)"
+// @Param cursor query string false "NextCursor of the previous page"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param archived query bool false "Also search the archived entries, implied by a 'from' older than the retention"
+// @Param diff_only query bool false "Leave the Before/After snapshots out, keeping only the Diff"
+// @Success 200 {object} entity.LogPage
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /audit [get]
+func (h *AuditHandler) SearchLogs(c *gin.Context) {
+	filter := entity.LogFilter{
+		Actor:        c.Query("actor"),
+		Action:       entity.AuditAction(c.Query("action")),
+		ResourceID:   c.Query("resource_id"),
+		ResourceType: entity.ResourceType(c.Query("resource_type")),
+	}
+	filter.Archived, _ = strconv.ParseBool(c.Query("archived"))
+	var err error
+
+	if filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "50")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+	if v := c.Query("cursor"); v != "" {
+		if filter.Before, err = strconv.ParseInt(v, 10, 64); err != nil || filter.Before < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+	}
+	if filter.From, err = auditTime(c.Query("from"), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from', expected RFC3339 or YYYY-MM-DD"})
+		return
+	}
+	if filter.To, err = auditTime(c.Query("to"), true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to', expected RFC3339 or YYYY-MM-DD"})
+		return
+	}
+
+	page, err := h.service.SearchLogs(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	page.Entries = diffOnly(c, page.Entries)
+	c.JSON(http.StatusOK, page)
+}
+
+// @Summary List Audit Archives
+// @Description Files holding the entries moved out of the database by the retention job (AUDIT_RETENTION_DAYS), oldest first, with their sequence range and SHA-256
+// @Tags audit
+// @Produce json
+// @Success 200 {array} entity.AuditArchive
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /audit/archives [get]
+func (h *AuditHandler) ListArchives(c *gin.Context) {
+	archives, err := h.service.ListArchives(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, archives)
+}
+
+// @Summary Export a Signed Audit Bundle
+// @Description Zip with the matching entries (entries.ndjson, oldest first, archives included), a manifest with their count, sequence range and SHA-256 (manifest.json) and its Ed25519 signature (manifest.sig). Check it offline with ./audit verify-bundle.
+// @Tags audit
+// @Produce application/zip
+// @Param actor header string false "The name of the user performing the export"
+// @Param from query string false "On or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Before (RFC3339), or on or before (YYYY-MM-DD)"
+// @Param resource_id query string false "ID of the affected resource"
+// @Success 200 {file} file "Bundle"
+// @Failure 400 {object} map[string]string "A range or resource is required"
+// @Failure 503 {object} map[string]string "No signing key configured"
+// @Router /audit/export [get]
+func (h *AuditHandler) ExportBundle(c *gin.Context) {
+	actor := c.GetHeader("actor")
+	filter := entity.LogFilter{ResourceID: c.Query("resource_id")}
+	var err error
+
+	if filter.From, err = auditTime(c.Query("from"), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from', expected RFC3339 or YYYY-MM-DD"})
+		return
+	}
+	if filter.To, err = auditTime(c.Query("to"), true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to', expected RFC3339 or YYYY-MM-DD"})
+		return
+	}
+
+	var buf bytes.Buffer
+	m, err := h.service.ExportBundle(c.Request.Context(), actor, filter, &buf)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="audit-bundle-`+m.ID.String()+`.zip"`)
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// @Summary Stream Audit Logs
+// @Description Server-Sent Events feed of the new audit entries as they are committed, one event per entry with its Sequence as id. Reconnecting with Last-Event-ID resumes after that entry, from the entries still in the database. A comment line is sent every 15s while idle.
+// @Tags audit
+// @Produce text/event-stream
+// @Param action query string false "Action, e.g. BUSINESS_COMMISSION_UPDATED"
+// @Param resource_type query string false "business, merchant, transaction or terminal"
+// @Param Last-Event-ID header int false "Resume after this sequence"
+// @Param last_event_id query int false "Same as the header, for clients that can't set it"
+// @Success 200 {object} entity.Log "One data line per event"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /audit/stream [get]
+func (h *AuditHandler) StreamLogs(c *gin.Context) {
+	filter := entity.LogFilter{
+		Action:       entity.AuditAction(c.Query("action")),
+		ResourceType: entity.ResourceType(c.Query("resource_type")),
+	}
+	afterSeq := int64(-1)
+	last := c.GetHeader("Last-Event-ID")
+	if last == "" {
+		last = c.Query("last_event_id")
+	}
+	if last != "" {
+		seq, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || seq < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return
+		}
+		afterSeq = seq
+	}
+
+	open := false
+	err := h.service.StreamLogs(c.Request.Context(), filter, afterSeq, func(l *entity.Log) error {
+		if !open {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no") // nginx would hold the events back
+			c.Status(http.StatusOK)
+			open = true
+		}
+		var err error
+		if l == nil {
+			_, err = io.WriteString(c.Writer, ": ping\n\n")
+		} else {
+			data, merr := json.Marshal(l)
+			if merr != nil {
+				return merr
+			}
+			_, err = fmt.Fprintf(c.Writer, "id: %d\ndata: %s\n\n", l.Sequence, data)
+		}
+		c.Writer.Flush()
+		return err
+	})
+	if err != nil && !open {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+	}
+}
+
+// auditTime parses an audit search bound, nil when empty. A plain date used
+// as the upper bound covers that whole day (UTC).
+func auditTime(v string, upper bool) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// asOfTime parses a point in time, a plain date means the end of that day
+// (UTC)
+func asOfTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.AddDate(0, 0, 1), nil
+}
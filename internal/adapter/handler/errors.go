@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/gin-gonic/gin"
+)
+
+// errorStatus maps domain errors to an HTTP status, unknown errors keep the
+// endpoint's fallback status
+func errorStatus(err error, fallback int) int {
+	var verr *entity.ValidationError
+	if errors.As(err, &verr) {
+		return http.StatusBadRequest
+	}
+	var nerr *entity.NotFoundError
+	if errors.As(err, &nerr) {
+		return http.StatusNotFound
+	}
+	var rerr *entity.RejectedError
+	if errors.As(err, &rerr) {
+		switch rerr.Code {
+		case entity.CodeInvalidStatusTransition, entity.CodeBusinessHasMerchants,
+			entity.CodeNotDeleted, entity.CodeParentDeleted,
+			entity.CodeHierarchyCycle, entity.CodeBusinessHasChildren, entity.CodeDuplicateSerial,
+			entity.CodeAlreadyRefunded:
+			return http.StatusConflict
+		case entity.CodeExportDisabled:
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusUnprocessableEntity
+	}
+	return fallback
+}
+
+// errorBody is the error payload, rejections also carry their code
+func errorBody(err error) gin.H {
+	var rerr *entity.RejectedError
+	if errors.As(err, &rerr) {
+		return gin.H{"error": rerr.Reason, "code": rerr.Code}
+	}
+	return gin.H{"error": err.Error()}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/CardenalDex/crudprotec/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type InvoiceHandler struct {
+	service usecase.InvoiceUseCase
+}
+
+func NewInvoiceHandler(s usecase.InvoiceUseCase) *InvoiceHandler {
+	return &InvoiceHandler{service: s}
+}
+
+// @Summary Generate a commission invoice (CFDI 4.0 XML)
+// @Description Builds the CFDI for the commissions charged to a business over a billing period, validated against the bundled XSD
+// @Tags invoices
+// @Produce xml
+// @Param actor header string false "The name of the user performing the action"
+// @Param id path string true "Business UUID"
+// @Param from query string true "Period start date (YYYY-MM-DD, inclusive)"
+// @Param to query string true "Period end date (YYYY-MM-DD, inclusive)"
+// @Success 200 {string} string "CFDI XML document"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Business not found"
+// @Failure 422 {object} map[string]string "Business cannot be invoiced"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/businesses/{id}/invoice [get]
+func (h *InvoiceHandler) GenerateCommissionInvoice(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	actor := c.GetHeader("actor")
+
+	from, err := time.Parse("2006-01-02", c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' date, expected YYYY-MM-DD"})
+		return
+	}
+	to, err := time.Parse("2006-01-02", c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' date, expected YYYY-MM-DD"})
+		return
+	}
+
+	// "to" is inclusive for the caller, the period is [from, to+1d)
+	inv, err := h.service.GenerateCommissionInvoice(c.Request.Context(), actor, id, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\"cfdi-"+inv.Folio+".xml\"")
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", inv.XML)
+}
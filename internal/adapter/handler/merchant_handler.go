@@ -2,8 +2,10 @@ package handler
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
-	_ "github.com/CardenalDex/crudprotec/internal/entitys" // needed for swager
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
 	"github.com/CardenalDex/crudprotec/internal/usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -11,14 +13,65 @@ import (
 
 type MerchantHandler struct {
 	service usecase.MerchantUseCase
+	audit   usecase.AuditUseCase // as_of reads
 }
 
-func NewMerchantHandler(s usecase.MerchantUseCase) *MerchantHandler {
-	return &MerchantHandler{service: s}
+func NewMerchantHandler(s usecase.MerchantUseCase, a usecase.AuditUseCase) *MerchantHandler {
+	return &MerchantHandler{service: s, audit: a}
 }
 
 type createMerchantRequest struct {
-	BusinessID string `json:"business_id" binding:"required"`
+	BusinessID   string         `json:"business_id" binding:"required"`
+	DisplayName  string         `json:"display_name"`
+	MCC          string         `json:"mcc"` // ISO 18245, see GET /merchants/mcc
+	Location     addressRequest `json:"location"`
+	ContactEmail string         `json:"contact_email"`
+	Phone        string         `json:"phone"`
+	Status       string         `json:"status"` // onboarding or active (default)
+}
+
+// updateMerchantProfileRequest: omitted fields are left unchanged
+type updateMerchantProfileRequest struct {
+	DisplayName  *string         `json:"display_name"`
+	MCC          *string         `json:"mcc"`
+	Location     *addressRequest `json:"location"`
+	ContactEmail *string         `json:"contact_email"`
+	Phone        *string         `json:"phone"`
+}
+
+func (r updateMerchantProfileRequest) toEntity() entity.MerchantProfilePatch {
+	patch := entity.MerchantProfilePatch{
+		DisplayName:  trimField(r.DisplayName, nil),
+		MCC:          trimField(r.MCC, nil),
+		ContactEmail: trimField(r.ContactEmail, nil),
+		Phone:        trimField(r.Phone, normalizePhone),
+	}
+	if r.Location != nil {
+		loc := r.Location.toEntity()
+		patch.Location = &loc
+	}
+	return patch
+}
+
+type changeStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+type transferMerchantRequest struct {
+	BusinessID  string `json:"business_id" binding:"required"`
+	EffectiveAt string `json:"effective_at"` // RFC3339, defaults to now
+}
+
+type commissionOverrideRequest struct {
+	Commission *float64 `json:"commission_percentage" binding:"required,gte=0"` // e.g., 3.5 for 3.5%, 0 is allowed
+	ExpiresAt  string   `json:"expires_at"`                                     // RFC3339, empty never expires
+}
+
+type mccResponse struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Group       string `json:"group"`
 }
 
 // --- Handlers ---
@@ -47,9 +100,18 @@ func (h *MerchantHandler) RegisterMerchant(c *gin.Context) {
 		return
 	}
 
-	merchant, err := h.service.RegisterMerchant(c.Request.Context(), actor, bizUUID)
+	profile := entity.MerchantProfile{
+		DisplayName:  strings.TrimSpace(req.DisplayName),
+		MCC:          strings.TrimSpace(req.MCC),
+		Location:     req.Location.toEntity(),
+		ContactEmail: strings.TrimSpace(req.ContactEmail),
+		Phone:        normalizePhone(strings.TrimSpace(req.Phone)),
+	}
+
+	status := entity.MerchantStatus(strings.ToLower(strings.TrimSpace(req.Status)))
+	merchant, err := h.service.RegisterMerchant(c.Request.Context(), actor, bizUUID, profile, status)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -57,13 +119,16 @@ func (h *MerchantHandler) RegisterMerchant(c *gin.Context) {
 }
 
 // @Summary Get Merchant Details
-// @Description Retrieve details of a specific merchant by ID
+// @Description Retrieve details of a specific merchant by ID. With as_of the merchant is rebuilt from the audit snapshots as it was at that time and returned with the audit entry it comes from.
 // @Tags merchants
 // @Produce json
 // @Param id path string true "Merchant UUID"
-// @Success 200 {object} entity.Merchant
+// @Param include_deleted query bool false "Also return soft deleted records"
+// @Param as_of query string false "Point in time (RFC3339), a plain YYYY-MM-DD means the end of that day"
+// @Success 200 {object} entity.Merchant "entity.HistoricalMerchant with as_of"
 // @Failure 400 {object} map[string]string "Invalid UUID"
 // @Failure 404 {object} map[string]string "Merchant not found"
+// @Failure 422 {object} map[string]string "HISTORY_UNAVAILABLE, the audit entry predates the snapshots"
 // @Router /merchants/{id} [get]
 func (h *MerchantHandler) GetMerchant(c *gin.Context) {
 	idParam := c.Param("id")
@@ -73,7 +138,22 @@ func (h *MerchantHandler) GetMerchant(c *gin.Context) {
 		return
 	}
 
-	merchant, err := h.service.GetMerchant(c.Request.Context(), id)
+	if v := c.Query("as_of"); v != "" {
+		at, err := asOfTime(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'as_of', expected RFC3339 or YYYY-MM-DD"})
+			return
+		}
+		hist, err := h.audit.GetMerchantAsOf(c.Request.Context(), id, at)
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusNotFound), errorBody(err))
+			return
+		}
+		c.JSON(http.StatusOK, hist)
+		return
+	}
+
+	merchant, err := h.service.GetMerchant(c.Request.Context(), id, includeDeleted(c))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -87,6 +167,7 @@ func (h *MerchantHandler) GetMerchant(c *gin.Context) {
 // @Tags merchants
 // @Produce json
 // @Param businessID path string true "Business UUID"
+// @Param include_deleted query bool false "Also return soft deleted records"
 // @Success 200 {array} entity.Merchant
 // @Failure 400 {object} map[string]string "Invalid UUID"
 // @Failure 500 {object} map[string]string "Internal Server Error"
@@ -99,7 +180,7 @@ func (h *MerchantHandler) GetBusinessMerchants(c *gin.Context) {
 		return
 	}
 
-	merchants, err := h.service.GetBusinessMerchants(c.Request.Context(), bizID)
+	merchants, err := h.service.GetBusinessMerchants(c.Request.Context(), bizID, includeDeleted(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -108,6 +189,276 @@ func (h *MerchantHandler) GetBusinessMerchants(c *gin.Context) {
 	c.JSON(http.StatusOK, merchants)
 }
 
+// @Summary Update Merchant Profile
+// @Description Partial update of the merchant profile (display name, MCC, location and contact data). Omitted fields are left unchanged, each changed field is audited.
+// @Tags merchants
+// @Accept json
+// @Produce json
+// @Param actor header string false "The name of the user performing the action"
+// @Param id path string true "Merchant UUID"
+// @Param profile body updateMerchantProfileRequest true "Profile fields to change"
+// @Success 200 {object} entity.Merchant
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /merchants/{id} [patch]
+func (h *MerchantHandler) UpdateMerchantProfile(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	actor := c.GetHeader("actor")
+	var req updateMerchantProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	merchant, err := h.service.UpdateMerchantProfile(c.Request.Context(), actor, id, req.toEntity())
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, merchant)
+}
+
+// @Summary Change Merchant Status
+// @Description Move a merchant through onboarding -> active <-> suspended -> closed (closed is final). Only active merchants can process transactions.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param actor header string false "The name of the user performing the action"
+// @Param id path string true "Merchant UUID"
+// @Param status body changeStatusRequest true "Target status and reason"
+// @Success 200 {object} entity.Merchant
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 409 {object} map[string]string "Transition not allowed (code INVALID_STATUS_TRANSITION)"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/merchants/{id}/status [patch]
+func (h *MerchantHandler) ChangeMerchantStatus(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	actor := c.GetHeader("actor")
+	var req changeStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := entity.MerchantStatus(strings.ToLower(strings.TrimSpace(req.Status)))
+	merchant, err := h.service.ChangeMerchantStatus(c.Request.Context(), actor, id, status, strings.TrimSpace(req.Reason))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, merchant)
+}
+
+// @Summary Restore a Merchant
+// @Description Undo the soft delete of a merchant, its business must not be deleted
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param actor header string false "The name of the user performing the action"
+// @Param id path string true "Merchant UUID"
+// @Param restore body restoreRequest true "Reason of the restore"
+// @Success 200 {object} entity.Merchant
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 409 {object} map[string]string "Merchant not deleted or business deleted (code NOT_DELETED or PARENT_DELETED)"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/merchants/{id}/restore [post]
+func (h *MerchantHandler) RestoreMerchant(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	actor := c.GetHeader("actor")
+	var req restoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	merchant, err := h.service.RestoreMerchant(c.Request.Context(), actor, id, strings.TrimSpace(req.Reason))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, merchant)
+}
+
+// @Summary Transfer a Merchant
+// @Description Move a merchant to another business from effective_at (default now, cannot be in the future) on. Transactions keep the commission they were charged, the ones processed since effective_at are attributed to the new business in reports and invoices.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param actor header string false "The name of the user performing the action"
+// @Param id path string true "Merchant UUID"
+// @Param transfer body transferMerchantRequest true "New business and effective time"
+// @Success 200 {object} entity.MerchantTransfer
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/merchants/{id}/transfer [post]
+func (h *MerchantHandler) TransferMerchant(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	actor := c.GetHeader("actor")
+	var req transferMerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	bizUUID, err := uuid.Parse(req.BusinessID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Business UUID"})
+		return
+	}
+	var effectiveAt time.Time
+	if req.EffectiveAt != "" {
+		if effectiveAt, err = time.Parse(time.RFC3339, req.EffectiveAt); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid effective_at, expected RFC3339"})
+			return
+		}
+	}
+
+	transfer, err := h.service.TransferMerchant(c.Request.Context(), actor, id, bizUUID, effectiveAt)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// @Summary Merchant Ownership History
+// @Description Businesses that owned the merchant and when, the current owner has no ValidTo
+// @Tags merchants
+// @Produce json
+// @Param id path string true "Merchant UUID"
+// @Success 200 {array} entity.MerchantOwnership
+// @Failure 400 {object} map[string]string "Invalid UUID"
+// @Failure 404 {object} map[string]string "Merchant not found"
+// @Router /merchants/{id}/ownership [get]
+func (h *MerchantHandler) GetMerchantOwnership(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	history, err := h.service.GetMerchantOwnership(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// @Summary Set Merchant Commission Override
+// @Description Charge this merchant its own commission instead of the business rate, until expires_at if given
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param actor header string false "The name of the user performing the action"
+// @Param id path string true "Merchant UUID"
+// @Param commission body commissionOverrideRequest true "Override rate and optional expiry"
+// @Success 200 {object} entity.Merchant
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/merchants/{id}/commission [patch]
+func (h *MerchantHandler) SetCommissionOverride(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	actor := c.GetHeader("actor")
+	var req commissionOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Convert Percentage -> Basis Points
+	override := &entity.CommissionOverride{Rate: int64(*req.Commission * 100)}
+	if req.ExpiresAt != "" {
+		exp, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expires_at, expected RFC3339"})
+			return
+		}
+		override.ExpiresAt = &exp
+	}
+
+	merchant, err := h.service.SetCommissionOverride(c.Request.Context(), actor, id, override)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, merchant)
+}
+
+// @Summary Remove Merchant Commission Override
+// @Description The merchant goes back to paying the business rate
+// @Tags admin
+// @Produce json
+// @Param actor header string false "The name of the user performing the action"
+// @Param id path string true "Merchant UUID"
+// @Success 200 {object} entity.Merchant
+// @Failure 400 {object} map[string]string "Invalid UUID"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/merchants/{id}/commission [delete]
+func (h *MerchantHandler) ClearCommissionOverride(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	actor := c.GetHeader("actor")
+
+	merchant, err := h.service.SetCommissionOverride(c.Request.Context(), actor, id, nil)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, merchant)
+}
+
+// @Summary List Merchant Category Codes
+// @Description The bundled ISO 18245 MCC table accepted for merchant profiles
+// @Tags merchants
+// @Produce json
+// @Success 200 {array} mccResponse
+// @Router /merchants/mcc [get]
+func (h *MerchantHandler) ListMCCCodes(c *gin.Context) {
+	codes := entity.MCCCodes()
+	out := make([]mccResponse, len(codes))
+	for i, m := range codes {
+		out[i] = mccResponse{Code: m.Code, Description: m.Description, Group: m.Group()}
+	}
+	c.JSON(http.StatusOK, out)
+}
+
 // @Summary Remove a Merchant
 // @Description Logic delete of a merchant
 // @Tags merchants
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/CardenalDex/crudprotec/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReportHandler struct {
+	service   usecase.ReportUseCase
+	scheduled usecase.ScheduledReportUseCase
+}
+
+func NewReportHandler(s usecase.ReportUseCase, sr usecase.ScheduledReportUseCase) *ReportHandler {
+	return &ReportHandler{service: s, scheduled: sr}
+}
+
+// @Summary Top merchants, businesses, business groups or terminals
+// @Description Ranks merchants, businesses, whole business groups (attributed to their top level business) or POS terminals by volume, fee revenue or transaction count over a period, with the change versus the previous period of the same length
+// @Tags reports
+// @Produce json
+// @Param group query string false "merchant (default), business, business_group or terminal"
+// @Param metric query string false "volume (default), fees or count"
+// @Param from query string true "Period start date (YYYY-MM-DD, inclusive)"
+// @Param to query string true "Period end date (YYYY-MM-DD, inclusive)"
+// @Param limit query int false "Number of entries (default 10, max 100)"
+// @Param sort query string false "value (default) ranks the top performers, decline ranks the biggest drops first"
+// @Success 200 {object} entity.Leaderboard
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reports/leaderboard [get]
+func (h *ReportHandler) Leaderboard(c *gin.Context) {
+	from, err := time.Parse("2006-01-02", c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' date, expected YYYY-MM-DD"})
+		return
+	}
+	to, err := time.Parse("2006-01-02", c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' date, expected YYYY-MM-DD"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+
+	group := entity.ReportGroup(c.DefaultQuery("group", string(entity.GroupByMerchant)))
+	metric := entity.ReportMetric(c.DefaultQuery("metric", string(entity.MetricVolume)))
+	byDecline := c.Query("sort") == "decline"
+
+	board, err := h.service.Leaderboard(c.Request.Context(), group, metric, from, to.AddDate(0, 0, 1), limit, byDecline)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, board)
+}
+
+// @Summary Business group rollup
+// @Description Consolidated figures of a business and all its descendants. Every node reports its own merchants, count, volume and fees and the total of its subtree.
+// @Tags reports
+// @Produce json
+// @Param id path string true "Business UUID"
+// @Param from query string true "Period start date (YYYY-MM-DD, inclusive)"
+// @Param to query string true "Period end date (YYYY-MM-DD, inclusive)"
+// @Success 200 {object} entity.BusinessRollup
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Business not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reports/businesses/{id}/rollup [get]
+func (h *ReportHandler) BusinessRollup(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	from, err := time.Parse("2006-01-02", c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' date, expected YYYY-MM-DD"})
+		return
+	}
+	to, err := time.Parse("2006-01-02", c.Query("to"))
+	if err != nil || to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' date, expected YYYY-MM-DD not before 'from'"})
+		return
+	}
+
+	rollup, err := h.service.BusinessRollup(c.Request.Context(), id, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, rollup)
+}
+
+// @Summary Scheduled report run history
+// @Description List the runs (one per attempt) of the scheduled reports, newest first
+// @Tags reports
+// @Produce json
+// @Param report query string false "daily_revenue, merchant_statements or reconciliation"
+// @Param status query string false "running, succeeded or failed"
+// @Param limit query int false "Number of runs (default 50, max 500)"
+// @Success 200 {array} entity.ReportRun
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reports/runs [get]
+func (h *ReportHandler) ListReportRuns(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+	report := entity.ScheduledReport(c.Query("report"))
+	status := entity.ReportRunStatus(c.Query("status"))
+
+	runs, err := h.scheduled.ListReportRuns(c.Request.Context(), report, status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// @Summary Run a scheduled report now
+// @Description Generates the report for yesterday (UTC) into the outbox without waiting for its schedule. Not retried.
+// @Tags reports
+// @Produce json
+// @Param report path string true "daily_revenue, merchant_statements or reconciliation"
+// @Success 201 {object} entity.ReportRun
+// @Failure 400 {object} map[string]string "Unknown report"
+// @Failure 500 {object} map[string]string "Report failed"
+// @Router /reports/runs/{report} [post]
+func (h *ReportHandler) RunReport(c *gin.Context) {
+	report := entity.ScheduledReport(c.Param("report"))
+
+	run, err := h.scheduled.RunReport(c.Request.Context(), report, time.Now(), 1)
+	if err != nil && run == nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "run": run})
+		return
+	}
+
+	c.JSON(http.StatusCreated, run)
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/CardenalDex/crudprotec/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SimulatorHandler struct {
+	service usecase.SimulatorUseCase
+}
+
+func NewSimulatorHandler(s usecase.SimulatorUseCase) *SimulatorHandler {
+	return &SimulatorHandler{service: s}
+}
+
+type feeTierRequest struct {
+	MinAmount  float64 `json:"min_amount" binding:"gte=0"`            // e.g., 1000.00, applies from this amount up
+	Commission float64 `json:"commission_percentage" binding:"gte=0"` // e.g., 4.5 for 4.5%
+}
+
+type simulateCommissionRequest struct {
+	From       string           `json:"from" binding:"required"` // YYYY-MM-DD, inclusive
+	To         string           `json:"to" binding:"required"`   // YYYY-MM-DD, inclusive
+	Commission float64          `json:"commission_percentage" binding:"gte=0"`
+	Tiers      []feeTierRequest `json:"tiers" binding:"dive"`
+}
+
+// @Summary Simulate a commission change
+// @Description Replays the business's historical transactions under a proposed fee schedule and returns current versus simulated fee revenue per month. Transactions priced by a merchant override keep the fee they were charged and are counted in Overridden. Nothing is persisted.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Business UUID"
+// @Param schedule body simulateCommissionRequest true "Proposed fee schedule and period"
+// @Success 200 {object} entity.CommissionSimulation
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 422 {object} map[string]string "Simulation not possible"
+// @Router /admin/businesses/{id}/simulate-commission [post]
+func (h *SimulatorHandler) SimulateCommission(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	var req simulateCommissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	from, err := time.Parse("2006-01-02", req.From)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' date, expected YYYY-MM-DD"})
+		return
+	}
+	to, err := time.Parse("2006-01-02", req.To)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' date, expected YYYY-MM-DD"})
+		return
+	}
+
+	// Convert Percentage -> Basis Points and Float -> Cents
+	schedule := entity.FeeSchedule{Rate: int64(req.Commission * 100)}
+	for _, t := range req.Tiers {
+		schedule.Tiers = append(schedule.Tiers, entity.FeeTier{
+			MinAmount: int64(t.MinAmount * 100),
+			Rate:      int64(t.Commission * 100),
+		})
+	}
+
+	sim, err := h.service.SimulateCommission(c.Request.Context(), id, schedule, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sim)
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/CardenalDex/crudprotec/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TerminalHandler struct {
+	service usecase.TerminalUseCase
+}
+
+func NewTerminalHandler(s usecase.TerminalUseCase) *TerminalHandler {
+	return &TerminalHandler{service: s}
+}
+
+type createTerminalRequest struct {
+	MerchantID   string `json:"merchant_id" binding:"required"`
+	SerialNumber string `json:"serial_number" binding:"required"`
+	Model        string `json:"model"`
+}
+
+type deactivateTerminalRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// @Summary Register a POS Terminal
+// @Description Adds an active terminal to a merchant. Serial numbers are unique across the system.
+// @Tags terminals
+// @Accept json
+// @Produce json
+// @Param actor header string false "The name of the user performing the action"
+// @Param terminal body createTerminalRequest true "Terminal Registration"
+// @Success 201 {object} entity.Terminal
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 409 {object} map[string]string "Serial number already registered (code DUPLICATE_SERIAL_NUMBER)"
+// @Failure 422 {object} map[string]string "Merchant is closed (code MERCHANT_NOT_ACTIVE)"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /terminals/new [post]
+func (h *TerminalHandler) RegisterTerminal(c *gin.Context) {
+	var req createTerminalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	actor := c.GetHeader("actor")
+	merchantUUID, err := uuid.Parse(req.MerchantID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Merchant UUID"})
+		return
+	}
+
+	t, err := h.service.RegisterTerminal(c.Request.Context(), actor, merchantUUID, req.SerialNumber, req.Model)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, t)
+}
+
+// @Summary Get Terminal Details
+// @Description Retrieve a terminal, including its status and last seen time
+// @Tags terminals
+// @Produce json
+// @Param id path string true "Terminal UUID"
+// @Success 200 {object} entity.Terminal
+// @Failure 400 {object} map[string]string "Invalid UUID"
+// @Failure 404 {object} map[string]string "Terminal not found"
+// @Router /terminals/{id} [get]
+func (h *TerminalHandler) GetTerminal(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	t, err := h.service.GetTerminal(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// @Summary List Terminals by Merchant
+// @Description Retrieve every terminal of a merchant, active and inactive
+// @Tags terminals
+// @Produce json
+// @Param merchantID path string true "Merchant UUID"
+// @Success 200 {array} entity.Terminal
+// @Failure 400 {object} map[string]string "Invalid UUID"
+// @Failure 404 {object} map[string]string "Merchant not found"
+// @Router /terminals/bymerchant/{merchantID} [get]
+func (h *TerminalHandler) GetMerchantTerminals(c *gin.Context) {
+	mID, err := uuid.Parse(c.Param("merchantID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Merchant UUID format"})
+		return
+	}
+
+	terminals, err := h.service.GetMerchantTerminals(c.Request.Context(), mID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, terminals)
+}
+
+// @Summary Deactivate a Terminal
+// @Description Retire a terminal, it can no longer process transactions. Deactivation is final.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param actor header string false "The name of the user performing the action"
+// @Param id path string true "Terminal UUID"
+// @Param deactivate body deactivateTerminalRequest true "Reason of the deactivation"
+// @Success 200 {object} entity.Terminal
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 409 {object} map[string]string "Already inactive (code INVALID_STATUS_TRANSITION)"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/terminals/{id}/deactivate [post]
+func (h *TerminalHandler) DeactivateTerminal(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	actor := c.GetHeader("actor")
+	var req deactivateTerminalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, err := h.service.DeactivateTerminal(c.Request.Context(), actor, id, strings.TrimSpace(req.Reason))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// @Summary List transactions by Terminal
+// @Description Retrieve all transactions processed by a terminal
+// @Tags transactions
+// @Produce json
+// @Param terminalID path string true "Terminal UUID"
+// @Success 200 {array} entity.Transaction
+// @Failure 400 {object} map[string]string "Invalid UUID format"
+// @Failure 404 {object} map[string]string "Terminal not found"
+// @Router /transactions/byterminal/{terminalID} [get]
+func (h *TerminalHandler) GetTerminalTransactions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("terminalID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Terminal UUID format"})
+		return
+	}
+
+	transactions, err := h.service.GetTerminalTransactions(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, transactions)
+}
@@ -2,8 +2,9 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
-	_ "github.com/CardenalDex/crudprotec/internal/entitys" // neded for swagger
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
 	"github.com/CardenalDex/crudprotec/internal/usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -21,6 +22,7 @@ func NewTransactionHandler(s usecase.TransactionUseCase) *TransactionHandler {
 type createTransactionRequest struct {
 	MerchantID string  `json:"merchant_id" binding:"required"`
 	Amount     float64 `json:"amount" binding:"required,gt=0"` // Input as float for user friendliness (converted after)
+	TerminalID string  `json:"terminal_id"`                    // optional, POS device of the merchant
 }
 
 // @Summary Create a new transaction
@@ -32,6 +34,7 @@ type createTransactionRequest struct {
 // @Param transaction body createTransactionRequest true "Transaction Request"
 // @Success 201 {object} entity.Transaction
 // @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 422 {object} map[string]string "Rejected: code MERCHANT_NOT_ACTIVE, BUSINESS_SUSPENDED or TERMINAL_NOT_ACTIVE"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /transactions/new [post]
 func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
@@ -47,12 +50,22 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 		return
 	}
 
+	var terminalID *uuid.UUID
+	if req.TerminalID != "" {
+		t, err := uuid.Parse(req.TerminalID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Terminal UUID"})
+			return
+		}
+		terminalID = &t
+	}
+
 	// CONVERSION LAYER: Convert User Float ($200.00) -> System Int64 Cents (20000)
 	amountCents := int64(req.Amount * 100)
 
-	tx, err := h.service.ProcessTransaction(c.Request.Context(), actor, merchantUUID, amountCents)
+	tx, err := h.service.ProcessTransaction(c.Request.Context(), actor, merchantUUID, amountCents, terminalID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
 		return
 	}
 
@@ -85,6 +98,34 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 	c.JSON(http.StatusOK, tx)
 }
 
+// @Summary Refund a transaction
+// @Description Refunds the full amount, the refund is added to the merchant's daily summary of the refund day
+// @Tags transactions
+// @Produce json
+// @Param actor header string false "The name of the user performing the action"
+// @Param id path string true "Transaction UUID"
+// @Success 200 {object} entity.Transaction
+// @Failure 400 {object} map[string]string "Invalid UUID format"
+// @Failure 404 {object} map[string]string "Transaction not found"
+// @Failure 409 {object} map[string]string "Rejected: code ALREADY_REFUNDED"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /transactions/{id}/refund [post]
+func (h *TransactionHandler) RefundTransaction(c *gin.Context) {
+	txID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	tx, err := h.service.RefundTransaction(c.Request.Context(), c.GetHeader("actor"), txID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, tx)
+}
+
 // @Summary List transactions by Merchant
 // @Description Retrieve all transactions belonging to a specific merchant
 // @Tags transactions
@@ -170,3 +211,47 @@ func (h *TransactionHandler) GetAllRevenueByMerchant(c *gin.Context) {
 
 	c.JSON(http.StatusOK, transactions)
 }
+
+// @Summary Daily summaries by Merchant
+// @Description Per-day transaction count, gross, fees and refunds of a merchant (served from the summary tables)
+// @Tags transactions
+// @Produce json
+// @Param merchantID path string true "Merchant UUID"
+// @Param from query string false "First day (YYYY-MM-DD, inclusive), defaults to 30 days ago"
+// @Param to query string false "Last day (YYYY-MM-DD, inclusive), defaults to today"
+// @Success 200 {array} entity.DailySummary
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /transactions/summaries/bymerchant/{merchantID} [get]
+func (h *TransactionHandler) GetMerchantDailySummaries(c *gin.Context) {
+	mParam := c.Param("merchantID")
+	mID, err := uuid.Parse(mParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Merchant UUID format"})
+		return
+	}
+
+	to := entity.SummaryDay(time.Now())
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' date, expected YYYY-MM-DD"})
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -30)
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' date, expected YYYY-MM-DD"})
+			return
+		}
+	}
+
+	// the service takes [from, to), the day after includes the last day
+	summaries, err := h.service.GetMerchantDailySummaries(c.Request.Context(), mID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summaries)
+}
@@ -0,0 +1,131 @@
+package outbox
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+)
+
+// Writer renders report tables as CSV files in
+// <root>/<report>/<period start>/ next to a manifest.json
+type Writer struct {
+	root string
+}
+
+func NewWriter(root string) *Writer {
+	return &Writer{root: root}
+}
+
+type manifest struct {
+	RunID        string         `json:"run_id"`
+	Report       string         `json:"report"`
+	ScheduledFor time.Time      `json:"scheduled_for"`
+	PeriodStart  time.Time      `json:"period_start"`
+	PeriodEnd    time.Time      `json:"period_end"`
+	Attempt      int            `json:"attempt"`
+	GeneratedAt  time.Time      `json:"generated_at"`
+	Files        []manifestFile `json:"files"`
+}
+
+type manifestFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Rows   int    `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+// Write replaces any previous output of the same report and period. Files are
+// staged in a temporary directory and renamed in place, so readers never see a
+// half written run.
+func (w *Writer) Write(_ context.Context, run *entity.ReportRun, tables []entity.ReportTable) (string, []entity.ReportFile, error) {
+	parent := filepath.Join(w.root, string(run.Report))
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", nil, err
+	}
+	dir := filepath.Join(parent, run.PeriodStart.Format("2006-01-02"))
+
+	staging, err := os.MkdirTemp(parent, ".staging-")
+	if err != nil {
+		return "", nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	files := make([]entity.ReportFile, 0, len(tables))
+	for _, t := range tables {
+		f, err := writeCSV(staging, t)
+		if err != nil {
+			return "", nil, err
+		}
+		files = append(files, f)
+	}
+
+	m := manifest{
+		RunID:        run.ID.String(),
+		Report:       string(run.Report),
+		ScheduledFor: run.ScheduledFor,
+		PeriodStart:  run.PeriodStart,
+		PeriodEnd:    run.PeriodEnd,
+		Attempt:      run.Attempt,
+		GeneratedAt:  time.Now(),
+	}
+	for _, f := range files {
+		m.Files = append(m.Files, manifestFile{Name: f.Name, Size: f.Size, Rows: f.Rows, SHA256: f.SHA256})
+	}
+	raw, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", nil, err
+	}
+	if err := os.WriteFile(filepath.Join(staging, "manifest.json"), raw, 0644); err != nil {
+		return "", nil, err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return "", nil, err
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		return "", nil, err
+	}
+	return dir, files, nil
+}
+
+func writeCSV(dir string, t entity.ReportTable) (entity.ReportFile, error) {
+	name := t.Name + ".csv"
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return entity.ReportFile{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	cw := csv.NewWriter(io.MultiWriter(f, h))
+	if err := cw.Write(t.Header); err != nil {
+		return entity.ReportFile{}, err
+	}
+	if err := cw.WriteAll(t.Rows); err != nil {
+		return entity.ReportFile{}, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return entity.ReportFile{}, err
+	}
+	if err := f.Sync(); err != nil {
+		return entity.ReportFile{}, fmt.Errorf("sync %s: %w", name, err)
+	}
+
+	return entity.ReportFile{
+		Name:   name,
+		Size:   info.Size(),
+		Rows:   len(t.Rows),
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
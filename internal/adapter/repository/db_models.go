@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"encoding/json"
 	"time"
 
 	entity "github.com/CardenalDex/crudprotec/internal/entitys"
@@ -9,121 +10,485 @@ import (
 )
 
 type BusinessModel struct {
-	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
-	Commission int64
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-	DeletedAt  gorm.DeletedAt `gorm:"index"`
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	ParentID     *uuid.UUID `gorm:"type:uuid;index"`
+	Commission   int64
+	LegalName    string
+	RFC          string `gorm:"index"`
+	TaxRegime    string
+	PostalCode   string
+	TradeName    string
+	ContactEmail string
+	Phone        string
+	Address      AddressModel `gorm:"embedded;embeddedPrefix:address_"`
+	StatusModel
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
 func (BusinessModel) TableName() string { return "businesses" }
 
+type AddressModel struct {
+	Street         string
+	ExteriorNumber string
+	InteriorNumber string
+	Neighborhood   string
+	City           string
+	State          string
+	PostalCode     string
+	Country        string
+}
+
+// StatusModel holds the lifecycle columns, rows created before statuses
+// existed default to active
+type StatusModel struct {
+	Status          string `gorm:"size:16;not null;default:active;index"`
+	StatusReason    string
+	StatusChangedAt *time.Time
+}
+
 type MerchantModel struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	BusinessID   uuid.UUID `gorm:"type:uuid;index"`
+	DisplayName  string
+	MCC          string       `gorm:"size:4;index"`
+	Location     AddressModel `gorm:"embedded;embeddedPrefix:location_"`
+	ContactEmail string
+	Phone        string
+	// nil when the merchant pays the business rate
+	CommissionOverride          *int64
+	CommissionOverrideExpiresAt *time.Time
+	StatusModel
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (MerchantModel) TableName() string { return "merchants" }
+
+type MerchantOwnershipModel struct {
 	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	MerchantID uuid.UUID `gorm:"type:uuid;index"`
 	BusinessID uuid.UUID `gorm:"type:uuid;index"`
+	ValidFrom  time.Time
+	ValidTo    *time.Time
+}
+
+func (MerchantOwnershipModel) TableName() string { return "merchant_ownerships" }
+
+type TerminalModel struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	MerchantID   uuid.UUID `gorm:"type:uuid;index"`
+	SerialNumber string    `gorm:"size:64;uniqueIndex"`
+	Model        string    `gorm:"size:64"`
+	StatusModel
+	LastSeenAt *time.Time
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
-	DeletedAt  gorm.DeletedAt `gorm:"index"`
 }
 
-func (MerchantModel) TableName() string { return "merchants" }
+func (TerminalModel) TableName() string { return "terminals" }
 
 type TransactionModel struct {
-	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
-	MerchantID uuid.UUID `gorm:"type:uuid;index"`
-	Amount     int64     // Stored in cents
-	Commission int64     // Calculated cents
-	Fee        int64
-	Timestamp  time.Time      `gorm:"index"`
-	DeletedAt  gorm.DeletedAt `gorm:"index"`
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	MerchantID uuid.UUID  `gorm:"type:uuid;index"`
+	BusinessID uuid.UUID  `gorm:"type:uuid;index"`
+	TerminalID *uuid.UUID `gorm:"type:uuid;index"`
+	MCC        string     `gorm:"size:4"`
+	Amount     int64      // Stored in cents
+	Commission int64      // Calculated cents
+	// rows older than overrides were all charged the business rate
+	CommissionSource string `gorm:"size:24;not null;default:business"`
+	Fee              int64
+	Timestamp        time.Time      `gorm:"index"`
+	RefundedAt       *time.Time     `gorm:"index"`
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
 }
 
 func (TransactionModel) TableName() string { return "transactions" }
 
+type DailySummaryModel struct {
+	MerchantID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Day        string    `gorm:"primaryKey;size:10"` // YYYY-MM-DD (UTC)
+	TxCount    int64
+	Gross      int64
+	Fees       int64
+	Refunds    int64
+}
+
+func (DailySummaryModel) TableName() string { return "merchant_daily_summaries" }
+
+type ReportRunModel struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Report       string    `gorm:"index"`
+	ScheduledFor time.Time `gorm:"index"`
+	PeriodStart  time.Time
+	PeriodEnd    time.Time
+	Attempt      int
+	Status       string `gorm:"index"`
+	Error        string
+	OutputDir    string
+	Files        int
+	StartedAt    time.Time
+	FinishedAt   *time.Time
+}
+
+func (ReportRunModel) TableName() string { return "report_runs" }
+
 type LogModel struct {
 	ID             uuid.UUID `gorm:"type:uuid;primaryKey"`
-	Action         string
-	Actor          string
-	ResourceID     string `gorm:"index"`
+	Action         string    `gorm:"index"`
+	Actor          string    `gorm:"index"`
+	ResourceID     string    `gorm:"index"`
+	ResourceType   string    `gorm:"index"`
+	LegacyAction   string
 	PrevResourceID string
+	Before         string    `gorm:"type:text"` // JSON snapshots, empty for older entries
+	After          string    `gorm:"type:text"`
 	Timestamp      time.Time `gorm:"index"`
+	Sequence       int64     `gorm:"index"`
+	PrevHash       string    `gorm:"size:64"`
+	Hash           string    `gorm:"size:64"`
 }
 
 func (LogModel) TableName() string { return "audit_logs" }
 
+type AuditAnchorModel struct {
+	Sequence  int64  `gorm:"primaryKey;autoIncrement:false"`
+	Hash      string `gorm:"size:64"`
+	CreatedAt time.Time
+}
+
+func (AuditAnchorModel) TableName() string { return "audit_anchors" }
+
+type AuditArchiveModel struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey"`
+	File          string
+	FirstSequence int64 `gorm:"uniqueIndex"`
+	LastSequence  int64
+	From          time.Time
+	To            time.Time
+	Entries       int
+	SHA256        string `gorm:"size:64"`
+	CreatedAt     time.Time
+}
+
+func (AuditArchiveModel) TableName() string { return "audit_archives" }
+
+type AccessLogModel struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Actor      string    `gorm:"index"`
+	Method     string
+	Route      string `gorm:"index"`
+	Path       string
+	ResourceID string `gorm:"index"`
+	Query      string `gorm:"type:text"` // JSON
+	Status     int
+	Timestamp  time.Time `gorm:"index"`
+}
+
+func (AccessLogModel) TableName() string { return "access_logs" }
+
 //////////////////////////////////////////////////////////////////////////
 
+// deletedAt maps gorm's soft delete marker, nil when the row is alive
+func deletedAt(d gorm.DeletedAt) *time.Time {
+	if !d.Valid {
+		return nil
+	}
+	t := d.Time
+	return &t
+}
+
+// utcPtr is the UTC copy of an optional time, the database keeps every time
+// in UTC (see InitInternalDB)
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
 func toBusinessModel(e *entity.Business) *BusinessModel {
 	return &BusinessModel{
-		ID:         e.ID,
-		Commission: e.Commission,
+		ID:           e.ID,
+		ParentID:     e.ParentID,
+		Commission:   e.Commission,
+		LegalName:    e.LegalName,
+		RFC:          e.RFC,
+		TaxRegime:    e.TaxRegime,
+		PostalCode:   e.PostalCode,
+		TradeName:    e.TradeName,
+		ContactEmail: e.ContactEmail,
+		Phone:        e.Phone,
+		Address:      AddressModel(e.Address),
+		StatusModel:  StatusModel{string(e.Status), e.StatusReason, utcPtr(e.StatusChangedAt)},
+		CreatedAt:    e.CreatedAt.UTC(),
+		UpdatedAt:    e.UpdatedAt.UTC(),
 	}
 }
 
 func (m *BusinessModel) toEntity() *entity.Business {
 	return &entity.Business{
 		ID:         m.ID,
+		ParentID:   m.ParentID,
 		Commission: m.Commission,
-		CreatedAt:  m.CreatedAt,
-		UpdatedAt:  m.UpdatedAt,
+		BusinessProfile: entity.BusinessProfile{
+			FiscalData: entity.FiscalData{
+				LegalName:  m.LegalName,
+				RFC:        m.RFC,
+				TaxRegime:  m.TaxRegime,
+				PostalCode: m.PostalCode,
+			},
+			TradeName:    m.TradeName,
+			ContactEmail: m.ContactEmail,
+			Phone:        m.Phone,
+			Address:      entity.Address(m.Address),
+		},
+		Status:       entity.BusinessStatus(m.Status),
+		StatusChange: entity.StatusChange{StatusReason: m.StatusReason, StatusChangedAt: m.StatusChangedAt},
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+		DeletedAt:    deletedAt(m.DeletedAt),
 	}
 }
 
 func toMerchantModel(e *entity.Merchant) *MerchantModel {
-	return &MerchantModel{
-		ID:         e.ID,
-		BusinessID: e.BusinessID,
+	m := &MerchantModel{
+		ID:           e.ID,
+		BusinessID:   e.BusinessID,
+		DisplayName:  e.DisplayName,
+		MCC:          e.MCC,
+		Location:     AddressModel(e.Location),
+		ContactEmail: e.ContactEmail,
+		Phone:        e.Phone,
+		StatusModel:  StatusModel{string(e.Status), e.StatusReason, utcPtr(e.StatusChangedAt)},
+		CreatedAt:    e.CreatedAt.UTC(),
+		UpdatedAt:    e.UpdatedAt.UTC(),
+	}
+	if o := e.CommissionOverride; o != nil {
+		m.CommissionOverride = &o.Rate
+		m.CommissionOverrideExpiresAt = utcPtr(o.ExpiresAt)
 	}
+	return m
 }
 
 func (m *MerchantModel) toEntity() *entity.Merchant {
-	return &entity.Merchant{
+	e := &entity.Merchant{
 		ID:         m.ID,
 		BusinessID: m.BusinessID,
-		CreatedAt:  m.CreatedAt,
-		DeletedAt:  &m.DeletedAt.Time,
+		MerchantProfile: entity.MerchantProfile{
+			DisplayName:  m.DisplayName,
+			MCC:          m.MCC,
+			Location:     entity.Address(m.Location),
+			ContactEmail: m.ContactEmail,
+			Phone:        m.Phone,
+		},
+		Status:       entity.MerchantStatus(m.Status),
+		StatusChange: entity.StatusChange{StatusReason: m.StatusReason, StatusChangedAt: m.StatusChangedAt},
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+		DeletedAt:    deletedAt(m.DeletedAt),
+	}
+	if m.CommissionOverride != nil {
+		e.CommissionOverride = &entity.CommissionOverride{Rate: *m.CommissionOverride, ExpiresAt: m.CommissionOverrideExpiresAt}
+	}
+	return e
+}
+
+func (m *MerchantOwnershipModel) toEntity() *entity.MerchantOwnership {
+	return &entity.MerchantOwnership{
+		MerchantID: m.MerchantID,
+		BusinessID: m.BusinessID,
+		ValidFrom:  m.ValidFrom,
+		ValidTo:    m.ValidTo,
 	}
 }
 
 func toTransactionModel(e *entity.Transaction) *TransactionModel {
 	return &TransactionModel{
-		ID:         e.ID,
-		MerchantID: e.MerchantID,
-		Amount:     e.Amount,
-		Commission: e.Commission,
-		Fee:        e.Fee,
-		Timestamp:  e.Timestamp,
+		ID:               e.ID,
+		MerchantID:       e.MerchantID,
+		BusinessID:       e.BusinessID,
+		TerminalID:       e.TerminalID,
+		MCC:              e.MCC,
+		Amount:           e.Amount,
+		Commission:       e.Commission,
+		CommissionSource: string(e.CommissionSource),
+		Fee:              e.Fee,
+		Timestamp:        e.Timestamp.UTC(),
+		RefundedAt:       utcPtr(e.RefundedAt),
 	}
 }
 
 func (m *TransactionModel) toEntity() *entity.Transaction {
 	return &entity.Transaction{
-		ID:         m.ID,
-		MerchantID: m.MerchantID,
-		Amount:     m.Amount,
-		Commission: m.Commission,
-		Fee:        m.Fee,
-		Timestamp:  m.Timestamp,
+		ID:               m.ID,
+		MerchantID:       m.MerchantID,
+		BusinessID:       m.BusinessID,
+		TerminalID:       m.TerminalID,
+		MCC:              m.MCC,
+		Amount:           m.Amount,
+		Commission:       m.Commission,
+		CommissionSource: entity.CommissionSource(m.CommissionSource),
+		Fee:              m.Fee,
+		Timestamp:        m.Timestamp,
+		RefundedAt:       m.RefundedAt,
+		DeletedAt:        deletedAt(m.DeletedAt),
+	}
+}
+
+func toTerminalModel(e *entity.Terminal) *TerminalModel {
+	return &TerminalModel{
+		ID:           e.ID,
+		MerchantID:   e.MerchantID,
+		SerialNumber: e.SerialNumber,
+		Model:        e.Model,
+		StatusModel:  StatusModel{string(e.Status), e.StatusReason, utcPtr(e.StatusChangedAt)},
+		LastSeenAt:   utcPtr(e.LastSeenAt),
+		CreatedAt:    e.CreatedAt.UTC(),
+		UpdatedAt:    e.UpdatedAt.UTC(),
+	}
+}
+
+func (m *TerminalModel) toEntity() *entity.Terminal {
+	return &entity.Terminal{
+		ID:           m.ID,
+		MerchantID:   m.MerchantID,
+		SerialNumber: m.SerialNumber,
+		Model:        m.Model,
+		Status:       entity.TerminalStatus(m.Status),
+		StatusChange: entity.StatusChange{StatusReason: m.StatusReason, StatusChangedAt: m.StatusChangedAt},
+		LastSeenAt:   m.LastSeenAt,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
 	}
 }
 
 func toLogModel(e *entity.Log) *LogModel {
 	return &LogModel{
 		ID:             e.ID,
-		Action:         e.Action,
+		Action:         string(e.Action),
 		Actor:          e.Actor,
 		ResourceID:     e.ResourceID,
+		ResourceType:   string(e.ResourceType),
+		LegacyAction:   e.LegacyAction,
 		PrevResourceID: e.PrevResourceID,
-		Timestamp:      e.Timestamp,
+		Before:         string(e.Before),
+		After:          string(e.After),
+		Timestamp:      e.Timestamp.UTC(),
+		Sequence:       e.Sequence,
+		PrevHash:       e.PrevHash,
+		Hash:           e.Hash,
 	}
 }
 func (m *LogModel) toEntity() *entity.Log {
-	return &entity.Log{
+	e := &entity.Log{
 		ID:             m.ID,
-		Action:         m.Action,
+		Action:         entity.AuditAction(m.Action),
 		Actor:          m.Actor,
 		ResourceID:     m.ResourceID,
+		ResourceType:   entity.ResourceType(m.ResourceType),
+		LegacyAction:   m.LegacyAction,
 		PrevResourceID: m.PrevResourceID,
 		Timestamp:      m.Timestamp,
+		Sequence:       m.Sequence,
+		PrevHash:       m.PrevHash,
+		Hash:           m.Hash,
+	}
+	if m.Before != "" {
+		e.Before = json.RawMessage(m.Before)
+	}
+	if m.After != "" {
+		e.After = json.RawMessage(m.After)
+	}
+	if e.Before != nil || e.After != nil {
+		e.Diff = entity.Diff(e.Before, e.After)
+	}
+	return e
+}
+
+func toAccessLogModel(e *entity.AccessLog) *AccessLogModel {
+	m := &AccessLogModel{
+		ID:         e.ID,
+		Actor:      e.Actor,
+		Method:     e.Method,
+		Route:      e.Route,
+		Path:       e.Path,
+		ResourceID: e.ResourceID,
+		Status:     e.Status,
+		Timestamp:  e.Timestamp.UTC(),
+	}
+	if len(e.Query) > 0 {
+		q, _ := json.Marshal(e.Query)
+		m.Query = string(q)
+	}
+	return m
+}
+
+func (m *AccessLogModel) toEntity() *entity.AccessLog {
+	e := &entity.AccessLog{
+		ID:         m.ID,
+		Actor:      m.Actor,
+		Method:     m.Method,
+		Route:      m.Route,
+		Path:       m.Path,
+		ResourceID: m.ResourceID,
+		Status:     m.Status,
+		Timestamp:  m.Timestamp,
+	}
+	if m.Query != "" {
+		_ = json.Unmarshal([]byte(m.Query), &e.Query)
+	}
+	return e
+}
+
+const summaryDayLayout = "2006-01-02"
+
+func (m *DailySummaryModel) toEntity() *entity.DailySummary {
+	day, _ := time.Parse(summaryDayLayout, m.Day)
+	return &entity.DailySummary{
+		MerchantID: m.MerchantID,
+		Day:        day,
+		Count:      m.TxCount,
+		Gross:      m.Gross,
+		Fees:       m.Fees,
+		Refunds:    m.Refunds,
+	}
+}
+
+func toReportRunModel(e *entity.ReportRun) *ReportRunModel {
+	return &ReportRunModel{
+		ID:           e.ID,
+		Report:       string(e.Report),
+		ScheduledFor: e.ScheduledFor.UTC(),
+		PeriodStart:  e.PeriodStart.UTC(),
+		PeriodEnd:    e.PeriodEnd.UTC(),
+		Attempt:      e.Attempt,
+		Status:       string(e.Status),
+		Error:        e.Error,
+		OutputDir:    e.OutputDir,
+		Files:        e.Files,
+		StartedAt:    e.StartedAt.UTC(),
+		FinishedAt:   utcPtr(e.FinishedAt),
+	}
+}
+
+func (m *ReportRunModel) toEntity() *entity.ReportRun {
+	return &entity.ReportRun{
+		ID:           m.ID,
+		Report:       entity.ScheduledReport(m.Report),
+		ScheduledFor: m.ScheduledFor,
+		PeriodStart:  m.PeriodStart,
+		PeriodEnd:    m.PeriodEnd,
+		Attempt:      m.Attempt,
+		Status:       entity.ReportRunStatus(m.Status),
+		Error:        m.Error,
+		OutputDir:    m.OutputDir,
+		Files:        m.Files,
+		StartedAt:    m.StartedAt,
+		FinishedAt:   m.FinishedAt,
 	}
 }
@@ -2,8 +2,11 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	entity "github.com/CardenalDex/crudprotec/internal/entitys"
 	"github.com/google/uuid"
@@ -11,26 +14,75 @@ import (
 	"gorm.io/gorm"
 )
 
-func InitInternalDB() *gorm.DB {
-	dbPath := "/app/data/local.db"
+func InitInternalDB(dir string) *gorm.DB {
+	dbPath := filepath.Join(dir, "local.db")
 
 	err := os.MkdirAll(filepath.Dir(dbPath), 0755)
 	if err != nil {
 		panic("failed to create internal database directory: " + err.Error())
 	}
 
-	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	// Every time is stored in UTC: sqlite compares them as text, a row written
+	// with another offset would sort and filter out of place
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
 	if err != nil {
 		panic("failed to connect to internal database: " + err.Error())
 	}
 
+	newSummaries := !db.Migrator().HasTable(&DailySummaryModel{})
+	newOwnership := !db.Migrator().HasTable(&MerchantOwnershipModel{})
+	newLogChain := !db.Migrator().HasColumn(&LogModel{}, "Sequence")
+	newActionCatalog := !db.Migrator().HasColumn(&LogModel{}, "ResourceType")
+
 	db.AutoMigrate(
 		&BusinessModel{},
 		&MerchantModel{},
+		&MerchantOwnershipModel{},
+		&TerminalModel{},
 		&TransactionModel{},
+		&DailySummaryModel{},
+		&ReportRunModel{},
 		&LogModel{},
+		&AuditAnchorModel{},
+		&AuditArchiveModel{},
+		&AccessLogModel{},
 	)
 
+	if err := normalizeTimestamps(db); err != nil {
+		panic("failed to normalize timestamps: " + err.Error())
+	}
+
+	// First boot with the hash chain: chain the existing entries in time order
+	if newLogChain {
+		if err := backfillLogChain(db); err != nil {
+			panic("failed to backfill the audit chain: " + err.Error())
+		}
+	}
+
+	// First boot with the action catalog: rename the old actions and type
+	// their resources (after the chain backfill, the hashes keep the old names)
+	if newActionCatalog {
+		if err := normalizeLogActions(db); err != nil {
+			panic("failed to normalize audit actions: " + err.Error())
+		}
+	}
+
+	// First boot with ownership history: the current owner owned it all along
+	if newOwnership {
+		if err := backfillOwnership(db); err != nil {
+			panic("failed to backfill merchant ownership: " + err.Error())
+		}
+	}
+
+	// First boot with summaries: backfill them from the existing transactions
+	if newSummaries {
+		if _, err := NewSQLiteRepository(db).RebuildDailySummaries(context.Background(), time.Time{}, time.Time{}); err != nil {
+			panic("failed to backfill daily summaries: " + err.Error())
+		}
+	}
+
 	return db
 }
 
@@ -46,46 +98,134 @@ func NewSQLiteRepository(db *gorm.DB) *sqliteRepo {
 
 func (r *sqliteRepo) CreateBusiness(ctx context.Context, b *entity.Business) error {
 	model := toBusinessModel(b)
-	return r.db.WithContext(ctx).Create(model).Error
+	return r.conn(ctx).Create(model).Error
 }
 
 func (r *sqliteRepo) GetBusinessByID(ctx context.Context, id uuid.UUID) (*entity.Business, error) {
 	var model BusinessModel
-	if err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+	if err := r.conn(ctx).First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &entity.NotFoundError{Resource: "business"}
+		}
+		return nil, err
+	}
+	return model.toEntity(), nil
+}
+
+func (r *sqliteRepo) GetBusinessIncludingDeleted(ctx context.Context, id uuid.UUID) (*entity.Business, error) {
+	var model BusinessModel
+	if err := r.conn(ctx).Unscoped().First(&model, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return model.toEntity(), nil
 }
 
+func (r *sqliteRepo) RestoreBusiness(ctx context.Context, id uuid.UUID) error {
+	return r.conn(ctx).Unscoped().Model(&BusinessModel{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "updated_at": time.Now().UTC()}).Error
+}
+
 func (r *sqliteRepo) UpdateBusiness(ctx context.Context, b *entity.Business) error {
 	model := toBusinessModel(b)
 
-	return r.db.WithContext(ctx).Save(model).Error
+	return r.conn(ctx).Save(model).Error
 }
 
-func (r *sqliteRepo) DeleteBusiness(ctx context.Context, id uuid.UUID) error {
-
-	return r.db.WithContext(ctx).Delete(&BusinessModel{}, "id = ?", id).Error
+// DeleteBusiness soft deletes the business and applies d.Policy to its
+// merchants in a single transaction, d.Merchants is filled with the merchants
+// that were deleted or reassigned
+func (r *sqliteRepo) DeleteBusiness(ctx context.Context, d *entity.BusinessDeletion) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&BusinessModel{}, "id = ?", d.BusinessID).Error; err != nil {
+			return err
+		}
+
+		var children int64
+		if err := tx.Model(&BusinessModel{}).Where("parent_id = ?", d.BusinessID).Count(&children).Error; err != nil {
+			return err
+		}
+		if children > 0 {
+			return &entity.RejectedError{
+				Code:   entity.CodeBusinessHasChildren,
+				Reason: fmt.Sprintf("business is the parent of %d businesses, move or delete them first", children),
+			}
+		}
+
+		var ids []uuid.UUID
+		if err := tx.Model(&MerchantModel{}).Where("business_id = ?", d.BusinessID).Order("created_at").Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+
+		if len(ids) > 0 {
+			switch d.Policy {
+			case entity.DeleteCascade:
+				if err := tx.Where("id IN ?", ids).Delete(&MerchantModel{}).Error; err != nil {
+					return err
+				}
+			case entity.DeleteReassign:
+				if d.TargetBusinessID == nil {
+					return &entity.ValidationError{Field: "target_business_id", Reason: "is required to reassign merchants"}
+				}
+				if err := tx.First(&BusinessModel{}, "id = ?", *d.TargetBusinessID).Error; err != nil {
+					return &entity.ValidationError{Field: "target_business_id", Reason: "business does not exist"}
+				}
+				now := time.Now()
+				for _, id := range ids {
+					if err := moveOwnership(tx, id, *d.TargetBusinessID, now); err != nil {
+						return err
+					}
+				}
+			default:
+				return &entity.RejectedError{
+					Code:   entity.CodeBusinessHasMerchants,
+					Reason: fmt.Sprintf("business still has %d merchants, use the cascade or reassign policy", len(ids)),
+				}
+			}
+		}
+
+		if err := tx.Delete(&BusinessModel{}, "id = ?", d.BusinessID).Error; err != nil {
+			return err
+		}
+		d.Merchants = ids
+		return nil
+	})
 }
 
 // --- MerchantRepository Implementation ---
 
 func (r *sqliteRepo) CreateMerchant(ctx context.Context, m *entity.Merchant) error {
 	model := toMerchantModel(m)
-	return r.db.WithContext(ctx).Create(model).Error
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+		return tx.Create(&MerchantOwnershipModel{ID: uuid.New(), MerchantID: m.ID, BusinessID: m.BusinessID, ValidFrom: m.CreatedAt}).Error
+	})
 }
 
 func (r *sqliteRepo) GetMerchantByID(ctx context.Context, id uuid.UUID) (*entity.Merchant, error) {
 	var model MerchantModel
-	if err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+	if err := r.conn(ctx).First(&model, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return model.toEntity(), nil
 }
 
-func (r *sqliteRepo) GetMerchantByBusinessID(ctx context.Context, bizID uuid.UUID) ([]entity.Merchant, error) {
+func (r *sqliteRepo) GetMerchantIncludingDeleted(ctx context.Context, id uuid.UUID) (*entity.Merchant, error) {
+	var model MerchantModel
+	if err := r.conn(ctx).Unscoped().First(&model, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return model.toEntity(), nil
+}
+
+func (r *sqliteRepo) GetMerchantByBusinessID(ctx context.Context, bizID uuid.UUID, includeDeleted bool) ([]entity.Merchant, error) {
 	var models []MerchantModel
-	if err := r.db.WithContext(ctx).Where("business_id = ?", bizID).Find(&models).Error; err != nil {
+	q := r.conn(ctx)
+	if includeDeleted {
+		q = q.Unscoped()
+	}
+	if err := q.Where("business_id = ?", bizID).Find(&models).Error; err != nil {
 		return nil, err
 	}
 
@@ -96,28 +236,123 @@ func (r *sqliteRepo) GetMerchantByBusinessID(ctx context.Context, bizID uuid.UUI
 	return merchants, nil
 }
 
+func (r *sqliteRepo) UpdateMerchant(ctx context.Context, m *entity.Merchant) error {
+	model := toMerchantModel(m)
+	return r.conn(ctx).Save(model).Error
+}
+
+func (r *sqliteRepo) RestoreMerchant(ctx context.Context, id uuid.UUID) error {
+	return r.conn(ctx).Unscoped().Model(&MerchantModel{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "updated_at": time.Now().UTC()}).Error
+}
+
 func (r *sqliteRepo) DeleteMerchant(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&MerchantModel{}, "id = ?", id).Error
+	return r.conn(ctx).Delete(&MerchantModel{}, "id = ?", id).Error
 }
 
 // --- TransactionRepository Implementation ---
 
 func (r *sqliteRepo) CreateTransaction(ctx context.Context, t *entity.Transaction) error {
 	model := toTransactionModel(t)
-	return r.db.WithContext(ctx).Create(model).Error
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+		if model.TerminalID != nil {
+			if err := tx.Model(&TerminalModel{}).Where("id = ?", *model.TerminalID).
+				UpdateColumn("last_seen_at", model.Timestamp).Error; err != nil {
+				return err
+			}
+		}
+		return applyToSummary(tx, model)
+	})
 }
 
 func (r *sqliteRepo) GetTransactionByID(ctx context.Context, id uuid.UUID) (*entity.Transaction, error) {
 	var model TransactionModel
-	if err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+	if err := r.conn(ctx).First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &entity.NotFoundError{Resource: "transaction"}
+		}
 		return nil, err
 	}
 	return model.toEntity(), nil
 }
 
+// RefundTransaction marks the transaction refunded at `at` and adds its
+// amount to the refunds of the merchant's summary row for that day
+func (r *sqliteRepo) RefundTransaction(ctx context.Context, id uuid.UUID, at time.Time) error {
+	at = at.UTC()
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var model TransactionModel
+		if err := tx.First(&model, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &entity.NotFoundError{Resource: "transaction"}
+			}
+			return err
+		}
+		res := tx.Model(&TransactionModel{}).Where("id = ? AND refunded_at IS NULL", id).UpdateColumn("refunded_at", at)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &entity.RejectedError{Code: entity.CodeAlreadyRefunded, Reason: "transaction is already refunded"}
+		}
+		return applyRefundToSummary(tx, model.MerchantID, at, model.Amount)
+	})
+}
+
 func (r *sqliteRepo) TransactionListByMerchant(ctx context.Context, mID uuid.UUID) ([]entity.Transaction, error) {
 	var models []TransactionModel
-	if err := r.db.WithContext(ctx).Where("merchant_id = ?", mID).Find(&models).Error; err != nil {
+	if err := r.conn(ctx).Where("merchant_id = ?", mID).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	transactions := make([]entity.Transaction, len(models))
+	for i, m := range models {
+		transactions[i] = *m.toEntity()
+	}
+	return transactions, nil
+}
+
+func (r *sqliteRepo) TransactionListByMerchantBetween(ctx context.Context, mID uuid.UUID, from, to time.Time) ([]entity.Transaction, error) {
+	var models []TransactionModel
+	if err := r.conn(ctx).
+		Where("merchant_id = ? AND timestamp >= ? AND timestamp < ?", mID, from.UTC(), to.UTC()).
+		Order("timestamp").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	transactions := make([]entity.Transaction, len(models))
+	for i, m := range models {
+		transactions[i] = *m.toEntity()
+	}
+	return transactions, nil
+}
+
+func (r *sqliteRepo) TransactionListByBusinessBetween(ctx context.Context, bizID uuid.UUID, from, to time.Time) ([]entity.Transaction, error) {
+	var models []TransactionModel
+	if err := r.conn(ctx).
+		Where("business_id = ? AND timestamp >= ? AND timestamp < ?", bizID, from.UTC(), to.UTC()).
+		Order("timestamp").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	transactions := make([]entity.Transaction, len(models))
+	for i, m := range models {
+		transactions[i] = *m.toEntity()
+	}
+	return transactions, nil
+}
+
+func (r *sqliteRepo) GetTransactionsBetween(ctx context.Context, from, to time.Time) ([]entity.Transaction, error) {
+	var models []TransactionModel
+	if err := r.conn(ctx).
+		Where("timestamp >= ? AND timestamp < ?", from.UTC(), to.UTC()).
+		Order("merchant_id, timestamp").
+		Find(&models).Error; err != nil {
 		return nil, err
 	}
 
@@ -130,7 +365,7 @@ func (r *sqliteRepo) TransactionListByMerchant(ctx context.Context, mID uuid.UUI
 
 func (r *sqliteRepo) GetAllTransaction(ctx context.Context) ([]entity.Transaction, error) {
 	var models []TransactionModel
-	if err := r.db.WithContext(ctx).Find(&models).Error; err != nil {
+	if err := r.conn(ctx).Find(&models).Error; err != nil {
 		return nil, err
 	}
 
@@ -143,14 +378,39 @@ func (r *sqliteRepo) GetAllTransaction(ctx context.Context) ([]entity.Transactio
 
 // --- LogRepository Implementation ---
 
+// CreateLog appends the entry to the hash chain, its ResourceType comes
+// from the action catalog
 func (r *sqliteRepo) CreateLog(ctx context.Context, l *entity.Log) error {
-	model := toLogModel(l)
-	return r.db.WithContext(ctx).Create(model).Error
+	if !l.Action.Valid() {
+		return fmt.Errorf("audit action %q is not in the catalog", l.Action)
+	}
+	l.ResourceType = l.Action.ResourceType()
+	if !inUnitOfWork(ctx) {
+		chainMu.Lock()
+		defer chainMu.Unlock()
+	}
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		head, err := chainHead(tx)
+		if err != nil {
+			return err
+		}
+		l.Sequence = head.Sequence + 1
+		l.PrevHash = head.Hash
+		l.Hash = l.ComputeHash()
+		return tx.Create(toLogModel(l)).Error
+	})
+	if err == nil && !logWritten(ctx) {
+		logFeed.notify()
+	}
+	return err
 }
 
 func (r *sqliteRepo) GetLogByID(ctx context.Context, id string) (entity.Log, error) {
 	var model LogModel
-	if err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+	if err := r.conn(ctx).First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.Log{}, &entity.NotFoundError{Resource: "audit entry"}
+		}
 		return entity.Log{}, err
 	}
 	return *model.toEntity(), nil
@@ -158,7 +418,7 @@ func (r *sqliteRepo) GetLogByID(ctx context.Context, id string) (entity.Log, err
 
 func (r *sqliteRepo) GetLogByResource(ctx context.Context, resID string) ([]entity.Log, error) {
 	var models []LogModel
-	if err := r.db.WithContext(ctx).Where("resource_id = ?", resID).Find(&models).Error; err != nil {
+	if err := r.conn(ctx).Where("resource_id = ?", resID).Find(&models).Error; err != nil {
 		return nil, err
 	}
 
@@ -169,9 +429,33 @@ func (r *sqliteRepo) GetLogByResource(ctx context.Context, resID string) ([]enti
 	return logs, nil
 }
 
-func (r *sqliteRepo) GetAll(ctx context.Context) ([]entity.Log, error) {
+// SearchLogs returns up to f.Limit entries matching f, newest first
+func (r *sqliteRepo) SearchLogs(ctx context.Context, f entity.LogFilter) ([]entity.Log, error) {
+	q := r.conn(ctx).Model(&LogModel{})
+	if f.Actor != "" {
+		q = q.Where("actor = ?", f.Actor)
+	}
+	if f.Action != "" {
+		q = q.Where("action = ?", f.Action)
+	}
+	if f.ResourceID != "" {
+		q = q.Where("resource_id = ?", f.ResourceID)
+	}
+	if f.ResourceType != "" {
+		q = q.Where("resource_type = ?", f.ResourceType)
+	}
+	if f.From != nil {
+		q = q.Where("timestamp >= ?", f.From.UTC())
+	}
+	if f.To != nil {
+		q = q.Where("timestamp < ?", f.To.UTC())
+	}
+	if f.Before > 0 {
+		q = q.Where("sequence < ?", f.Before)
+	}
+
 	var models []LogModel
-	if err := r.db.WithContext(ctx).Find(&models).Error; err != nil {
+	if err := q.Order("sequence DESC").Limit(f.Limit).Find(&models).Error; err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+)
+
+// --- AccessLogRepository Implementation ---
+
+func (r *sqliteRepo) CreateAccessLog(ctx context.Context, a *entity.AccessLog) error {
+	return r.conn(ctx).Create(toAccessLogModel(a)).Error
+}
+
+// ListAccessLogs returns the matching records, newest first
+func (r *sqliteRepo) ListAccessLogs(ctx context.Context, f entity.AccessLogFilter) ([]entity.AccessLog, error) {
+	q := r.conn(ctx).Order("timestamp DESC").Limit(f.Limit)
+	if f.Actor != "" {
+		q = q.Where("actor = ?", f.Actor)
+	}
+	if f.ResourceID != "" {
+		q = q.Where("resource_id = ?", f.ResourceID)
+	}
+	if f.Route != "" {
+		q = q.Where("route = ?", f.Route)
+	}
+	if f.From != nil {
+		q = q.Where("timestamp >= ?", f.From.UTC())
+	}
+	if f.To != nil {
+		q = q.Where("timestamp < ?", f.To.UTC())
+	}
+
+	var models []AccessLogModel
+	if err := q.Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	logs := make([]entity.AccessLog, len(models))
+	for i, m := range models {
+		logs[i] = *m.toEntity()
+	}
+	return logs, nil
+}
+
+func (r *sqliteRepo) DeleteAccessLogsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res := r.conn(ctx).Where("timestamp < ?", cutoff.UTC()).Delete(&AccessLogModel{})
+	return res.RowsAffected, res.Error
+}
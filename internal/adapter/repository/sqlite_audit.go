@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"gorm.io/gorm"
+)
+
+// --- Audit hash chain ---
+
+// chainMu serializes appends, two writers reading the same head would fork
+// the chain. Units of work hold it until they commit (see Do).
+var chainMu sync.Mutex
+
+// chainHead returns the last chained entry, a zero model when the chain is
+// empty
+func chainHead(tx *gorm.DB) (LogModel, error) {
+	var head LogModel
+	err := tx.Where("sequence > 0").Order("sequence DESC").Limit(1).Find(&head).Error
+	return head, err
+}
+
+// backfillLogChain chains the entries written before the hash chain existed,
+// oldest first
+func backfillLogChain(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		head, err := chainHead(tx)
+		if err != nil {
+			return err
+		}
+		for {
+			var batch []LogModel
+			if err := tx.Where("sequence = 0 OR sequence IS NULL").Order("timestamp, id").Limit(500).Find(&batch).Error; err != nil {
+				return err
+			}
+			if len(batch) == 0 {
+				return nil
+			}
+			for _, m := range batch {
+				l := m.toEntity()
+				l.Sequence = head.Sequence + 1
+				l.PrevHash = head.Hash
+				l.Hash = l.ComputeHash()
+				err := tx.Model(&LogModel{}).Where("id = ?", m.ID).
+					Updates(map[string]interface{}{"sequence": l.Sequence, "prev_hash": l.PrevHash, "hash": l.Hash}).Error
+				if err != nil {
+					return err
+				}
+				head = LogModel{Sequence: l.Sequence, Hash: l.Hash}
+			}
+		}
+	})
+}
+
+// normalizeLogActions moves the entries written before the action catalog to
+// it, see entity.Log.Normalize. Unknown actions are left untouched.
+func normalizeLogActions(db *gorm.DB) error {
+	var actions []string
+	if err := db.Model(&LogModel{}).Distinct().Pluck("action", &actions).Error; err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, name := range actions {
+			a, ok := entity.NormalizeAction(name)
+			if !ok {
+				continue
+			}
+			err := tx.Model(&LogModel{}).Where("action = ?", name).Updates(map[string]interface{}{
+				"action":        string(a),
+				"resource_type": string(a.ResourceType()),
+				"legacy_action": name,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *sqliteRepo) GetLogChainHead(ctx context.Context) (*entity.Log, error) {
+	head, err := chainHead(r.conn(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if head.Sequence == 0 {
+		return nil, errors.New("audit log is empty")
+	}
+	return head.toEntity(), nil
+}
+
+// ListLogChain returns up to limit entries after sequence afterSeq, in chain
+// order
+func (r *sqliteRepo) ListLogChain(ctx context.Context, afterSeq int64, limit int) ([]entity.Log, error) {
+	var models []LogModel
+	if err := r.conn(ctx).Where("sequence > ?", afterSeq).Order("sequence").Limit(limit).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	logs := make([]entity.Log, len(models))
+	for i, m := range models {
+		logs[i] = *m.toEntity()
+	}
+	return logs, nil
+}
+
+func (r *sqliteRepo) CreateAuditAnchor(ctx context.Context, a *entity.AuditAnchor) error {
+	m := AuditAnchorModel{Sequence: a.Sequence, Hash: a.Hash, CreatedAt: a.CreatedAt.UTC()}
+	return r.conn(ctx).Create(&m).Error
+}
+
+func (r *sqliteRepo) ListAuditAnchors(ctx context.Context) ([]entity.AuditAnchor, error) {
+	var models []AuditAnchorModel
+	if err := r.conn(ctx).Order("sequence").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	anchors := make([]entity.AuditAnchor, len(models))
+	for i, m := range models {
+		anchors[i] = entity.AuditAnchor{Sequence: m.Sequence, Hash: m.Hash, CreatedAt: m.CreatedAt}
+	}
+	return anchors, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"gorm.io/gorm"
+)
+
+// --- AuditArchiveRepository Implementation ---
+
+// ListArchivableLogs returns up to limit of the oldest entries, in sequence
+// order, stopping at the first one written at or after cutoff so what stays
+// in the table is still a contiguous chain. The head always stays, new
+// entries link to it.
+func (r *sqliteRepo) ListArchivableLogs(ctx context.Context, cutoff time.Time, limit int) ([]entity.Log, error) {
+	db := r.conn(ctx)
+	var bound struct {
+		Head  int64
+		Fresh *int64
+	}
+	err := db.Model(&LogModel{}).
+		Select("COALESCE(MAX(sequence),0) AS head, MIN(CASE WHEN timestamp >= ? THEN sequence END) AS fresh", cutoff.UTC()).
+		Scan(&bound).Error
+	if err != nil {
+		return nil, err
+	}
+	end := bound.Head
+	if bound.Fresh != nil && *bound.Fresh < end {
+		end = *bound.Fresh
+	}
+
+	var models []LogModel
+	if err := db.Where("sequence < ?", end).Order("sequence").Limit(limit).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	logs := make([]entity.Log, len(models))
+	for i, m := range models {
+		logs[i] = *m.toEntity()
+	}
+	return logs, nil
+}
+
+// SaveAuditArchive records the archive and drops its entries from the table
+func (r *sqliteRepo) SaveAuditArchive(ctx context.Context, a *entity.AuditArchive) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		m := AuditArchiveModel{
+			ID:            a.ID,
+			File:          a.File,
+			FirstSequence: a.FirstSequence,
+			LastSequence:  a.LastSequence,
+			From:          a.From.UTC(),
+			To:            a.To.UTC(),
+			Entries:       a.Entries,
+			SHA256:        a.SHA256,
+			CreatedAt:     a.CreatedAt.UTC(),
+		}
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+		return tx.Where("sequence BETWEEN ? AND ?", a.FirstSequence, a.LastSequence).Delete(&LogModel{}).Error
+	})
+}
+
+// ListAuditArchives returns the archives oldest first
+func (r *sqliteRepo) ListAuditArchives(ctx context.Context) ([]entity.AuditArchive, error) {
+	var models []AuditArchiveModel
+	if err := r.conn(ctx).Order("first_sequence").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	archives := make([]entity.AuditArchive, len(models))
+	for i, m := range models {
+		archives[i] = entity.AuditArchive{
+			ID:            m.ID,
+			File:          m.File,
+			FirstSequence: m.FirstSequence,
+			LastSequence:  m.LastSequence,
+			From:          m.From,
+			To:            m.To,
+			Entries:       m.Entries,
+			SHA256:        m.SHA256,
+			CreatedAt:     m.CreatedAt,
+		}
+	}
+	return archives, nil
+}
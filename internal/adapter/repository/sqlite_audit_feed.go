@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"sync"
+)
+
+// --- AuditFeed Implementation ---
+
+// logFeed wakes the subscribers once new audit entries are committed. Like
+// chainMu it is shared by every repository on the database. A wake up never
+// blocks the writer: a subscriber that has not consumed the previous one
+// reads every new entry from the database anyway.
+var logFeed = &feed{subs: map[chan struct{}]struct{}{}}
+
+type feed struct {
+	mu   sync.Mutex
+	subs map[chan struct{}]struct{}
+}
+
+func (f *feed) notify() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+type loggedKey struct{}
+
+// logWritten tells the unit of work in ctx to wake the feed after its
+// commit, outside one the caller does it right away
+func logWritten(ctx context.Context) bool {
+	if logged, ok := ctx.Value(loggedKey{}).(*bool); ok {
+		*logged = true
+		return true
+	}
+	return false
+}
+
+// SubscribeLogs returns a channel signalled after new entries are committed
+// and the function releasing it
+func (r *sqliteRepo) SubscribeLogs() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	logFeed.mu.Lock()
+	logFeed.subs[ch] = struct{}{}
+	logFeed.mu.Unlock()
+	return ch, func() {
+		logFeed.mu.Lock()
+		delete(logFeed.subs, ch)
+		logFeed.mu.Unlock()
+	}
+}
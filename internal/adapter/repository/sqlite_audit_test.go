@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CardenalDex/crudprotec/internal/adapter/archive"
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/CardenalDex/crudprotec/internal/usecase"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type auditFixture struct {
+	db         *gorm.DB
+	repo       *sqliteRepo
+	archiveDir string
+	audit      usecase.AuditUseCase
+}
+
+func newAuditFixture(t *testing.T) *auditFixture {
+	t.Helper()
+	db := InitInternalDB(t.TempDir())
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	repo := NewSQLiteRepository(db)
+	dir := t.TempDir()
+	return &auditFixture{
+		db:         db,
+		repo:       repo,
+		archiveDir: dir,
+		audit:      usecase.NewAuditService(repo, repo, repo, archive.NewStore(dir), nil, repo),
+	}
+}
+
+// writeLogs chains n entries written at the given time
+func (f *auditFixture) writeLogs(t *testing.T, n int, at time.Time) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		err := f.repo.CreateLog(context.Background(), &entity.Log{
+			ID:         uuid.New(),
+			Action:     entity.ActionBusinessProfileUpdated,
+			Actor:      "tester",
+			ResourceID: uuid.NewString(),
+			After:      []byte(`{"Phone":"5555123456"}`),
+			Timestamp:  at.Add(time.Duration(i) * time.Second),
+		})
+		if err != nil {
+			t.Fatalf("CreateLog: %v", err)
+		}
+	}
+}
+
+func (f *auditFixture) verify(t *testing.T) *entity.ChainVerification {
+	t.Helper()
+	v, err := f.audit.VerifyChain(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("VerifyChain: %v", err)
+	}
+	return v
+}
+
+// archivedChain leaves entries 1-5 and 6-10 in two archives and 11-13 in
+// the database
+func archivedChain(t *testing.T) (*auditFixture, []entity.AuditArchive) {
+	t.Helper()
+	f := newAuditFixture(t)
+	now := time.Now().UTC()
+	f.writeLogs(t, 5, now.Add(-72*time.Hour))
+	f.writeLogs(t, 5, now.Add(-48*time.Hour))
+	f.writeLogs(t, 3, now)
+
+	var archives []entity.AuditArchive
+	for _, cutoff := range []time.Time{now.Add(-60 * time.Hour), now.Add(-24 * time.Hour)} {
+		got, err := f.audit.ArchiveLogs(context.Background(), cutoff)
+		if err != nil {
+			t.Fatalf("ArchiveLogs: %v", err)
+		}
+		archives = append(archives, got...)
+	}
+	if len(archives) != 2 || archives[0].LastSequence != 5 || archives[1].FirstSequence != 6 || archives[1].LastSequence != 10 {
+		t.Fatalf("archives = %+v, want 1-5 and 6-10", archives)
+	}
+	return f, archives
+}
+
+func TestVerifyChainAcrossArchives(t *testing.T) {
+	f, _ := archivedChain(t)
+
+	v := f.verify(t)
+	if !v.Valid || v.Archives != 2 || v.Checked != 13 || v.LastSequence != 13 {
+		t.Fatalf("VerifyChain = %+v, want 13 valid entries over 2 archives", v)
+	}
+
+	// an anchor taken after archiving still matches
+	if _, err := f.audit.CreateAnchor(context.Background()); err != nil {
+		t.Fatalf("CreateAnchor: %v", err)
+	}
+	if v := f.verify(t); !v.Valid || v.Anchors != 1 {
+		t.Fatalf("VerifyChain = %+v, want valid with 1 anchor", v)
+	}
+}
+
+func TestVerifyChainAcrossArchivesDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(t *testing.T, f *auditFixture, archives []entity.AuditArchive)
+		wantSeq int64
+		reason  string
+	}{
+		{
+			name: "archive file removed",
+			tamper: func(t *testing.T, f *auditFixture, archives []entity.AuditArchive) {
+				if err := os.Remove(filepath.Join(f.archiveDir, archives[1].File)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantSeq: 6,
+			reason:  "no such file",
+		},
+		{
+			name: "archive file rewritten",
+			tamper: func(t *testing.T, f *auditFixture, archives []entity.AuditArchive) {
+				src := filepath.Join(f.archiveDir, archives[0].File)
+				if err := os.WriteFile(filepath.Join(f.archiveDir, archives[1].File), mustRead(t, src), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantSeq: 6,
+			reason:  "checksum",
+		},
+		{
+			name: "archive record dropped",
+			tamper: func(t *testing.T, f *auditFixture, archives []entity.AuditArchive) {
+				if err := f.db.Where("id = ?", archives[0].ID).Delete(&AuditArchiveModel{}).Error; err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantSeq: 1,
+			reason:  "missing",
+		},
+		{
+			name: "first entry after the archives edited",
+			tamper: func(t *testing.T, f *auditFixture, _ []entity.AuditArchive) {
+				if err := f.db.Model(&LogModel{}).Where("sequence = 11").Update("actor", "intruder").Error; err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantSeq: 11,
+			reason:  "modified",
+		},
+		{
+			name: "first entry after the archives deleted",
+			tamper: func(t *testing.T, f *auditFixture, _ []entity.AuditArchive) {
+				if err := f.db.Where("sequence = 11").Delete(&LogModel{}).Error; err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantSeq: 11,
+			reason:  "missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, archives := archivedChain(t)
+			tt.tamper(t, f, archives)
+
+			v := f.verify(t)
+			if v.Valid || v.Broken == nil {
+				t.Fatalf("VerifyChain = %+v, want a broken chain", v)
+			}
+			if v.Broken.Sequence != tt.wantSeq || !strings.Contains(v.Broken.Reason, tt.reason) {
+				t.Fatalf("Broken = %+v, want sequence %d and a reason with %q", v.Broken, tt.wantSeq, tt.reason)
+			}
+		})
+	}
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// legacyChain stores entries as written before the hash chain and the action
+// catalog, then runs both boot migrations on them
+func legacyChain(t *testing.T, actions ...string) *auditFixture {
+	t.Helper()
+	f := newAuditFixture(t)
+	at := time.Now().UTC().Add(-72 * time.Hour)
+	for i, action := range actions {
+		m := LogModel{
+			ID:             uuid.New(),
+			Action:         action,
+			Actor:          "legacy",
+			ResourceID:     uuid.NewString(),
+			PrevResourceID: "old_comm:500",
+			Timestamp:      at.Add(time.Duration(i) * time.Minute),
+		}
+		if err := f.db.Create(&m).Error; err != nil {
+			t.Fatalf("insert legacy entry: %v", err)
+		}
+	}
+	if err := backfillLogChain(f.db); err != nil {
+		t.Fatalf("backfillLogChain: %v", err)
+	}
+	if err := normalizeLogActions(f.db); err != nil {
+		t.Fatalf("normalizeLogActions: %v", err)
+	}
+	return f
+}
+
+func TestLegacyActionsKeepTheirHashes(t *testing.T) {
+	f := legacyChain(t, "CREATE_BUSINESS", "UPDATE_BUSINESS_COMMISSION", "SOMETHING_UNKNOWN", "UPDATE_MERCHANT_PROFILE")
+	// entries written after the migrations chain onto the legacy ones
+	f.writeLogs(t, 2, time.Now().UTC())
+
+	logs, err := f.repo.ListLogChain(context.Background(), 0, 10)
+	if err != nil {
+		t.Fatalf("ListLogChain: %v", err)
+	}
+	want := []struct {
+		action, legacy string
+		resource       entity.ResourceType
+	}{
+		{string(entity.ActionBusinessRegistered), "CREATE_BUSINESS", entity.ResourceBusiness},
+		{string(entity.ActionBusinessCommissionUpdated), "UPDATE_BUSINESS_COMMISSION", entity.ResourceBusiness},
+		{"SOMETHING_UNKNOWN", "", ""},
+		{string(entity.ActionMerchantProfileUpdated), "UPDATE_MERCHANT_PROFILE", entity.ResourceMerchant},
+		{string(entity.ActionBusinessProfileUpdated), "", entity.ResourceBusiness},
+		{string(entity.ActionBusinessProfileUpdated), "", entity.ResourceBusiness},
+	}
+	if len(logs) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(logs), len(want))
+	}
+	for i, w := range want {
+		l := logs[i]
+		if l.Sequence != int64(i+1) || string(l.Action) != w.action || l.LegacyAction != w.legacy || l.ResourceType != w.resource {
+			t.Errorf("entry %d = %d %s (legacy %q, %s), want %s (legacy %q, %s)",
+				i, l.Sequence, l.Action, l.LegacyAction, l.ResourceType, w.action, w.legacy, w.resource)
+		}
+	}
+
+	if v := f.verify(t); !v.Valid || v.Checked != 6 {
+		t.Fatalf("VerifyChain = %+v, want 6 valid entries", v)
+	}
+
+	// archived legacy entries are normalized again when read back
+	if _, err := f.audit.ArchiveLogs(context.Background(), time.Now().UTC().Add(-time.Hour)); err != nil {
+		t.Fatalf("ArchiveLogs: %v", err)
+	}
+	if v := f.verify(t); !v.Valid || v.Archives != 1 || v.Checked != 6 {
+		t.Fatalf("VerifyChain = %+v, want 6 valid entries with 1 archive", v)
+	}
+}
+
+func TestLegacyActionsDetectTampering(t *testing.T) {
+	tests := []struct {
+		name    string
+		updates map[string]interface{}
+		reason  string
+	}{
+		{"normalized action swapped", map[string]interface{}{"action": string(entity.ActionBusinessDeleted)}, "legacy action"},
+		{"resource type swapped", map[string]interface{}{"resource_type": string(entity.ResourceMerchant)}, "legacy action"},
+		{"legacy action rewritten", map[string]interface{}{"legacy_action": "DELETE_BUSINESS", "action": string(entity.ActionBusinessDeleted)}, "modified"},
+		{"legacy action dropped", map[string]interface{}{"legacy_action": ""}, "modified"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := legacyChain(t, "UPDATE_BUSINESS_PROFILE", "CREATE_BUSINESS", "DELETE_BUSINESS")
+			if err := f.db.Model(&LogModel{}).Where("sequence = 2").Updates(tt.updates).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			v := f.verify(t)
+			if v.Valid || v.Broken == nil || v.Broken.Sequence != 2 || !strings.Contains(v.Broken.Reason, tt.reason) {
+				t.Fatalf("VerifyChain = %+v (broken %+v), want entry 2 broken with %q", v, v.Broken, tt.reason)
+			}
+		})
+	}
+}
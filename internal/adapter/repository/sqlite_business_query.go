@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"gorm.io/gorm"
+)
+
+var businessSortColumns = map[string]string{
+	"created_at":     "b.created_at",
+	"updated_at":     "b.updated_at",
+	"commission":     "b.commission",
+	"legal_name":     "b.legal_name",
+	"merchant_count": "merchant_count",
+}
+
+func (r *sqliteRepo) ListBusinesses(ctx context.Context, f entity.BusinessFilter) ([]entity.BusinessListItem, int64, error) {
+	q := r.conn(ctx).Table("businesses AS b")
+
+	switch f.Deletion {
+	case entity.OnlyDeleted:
+		q = q.Where("b.deleted_at IS NOT NULL")
+	case entity.AnyDeletion:
+	default:
+		q = q.Where("b.deleted_at IS NULL")
+	}
+	if f.MinCommission != nil {
+		q = q.Where("b.commission >= ?", *f.MinCommission)
+	}
+	if f.MaxCommission != nil {
+		q = q.Where("b.commission <= ?", *f.MaxCommission)
+	}
+	if f.CreatedFrom != nil {
+		q = q.Where("b.created_at >= ?", f.CreatedFrom.UTC())
+	}
+	if f.CreatedTo != nil {
+		q = q.Where("b.created_at < ?", f.CreatedTo.UTC())
+	}
+
+	var total int64
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	sortCol, ok := businessSortColumns[f.SortBy]
+	if !ok {
+		sortCol = businessSortColumns["created_at"]
+	}
+	dir := " ASC"
+	if f.Desc {
+		dir = " DESC"
+	}
+
+	var rows []struct {
+		BusinessModel
+		MerchantCount int64
+	}
+	err := q.
+		Select("b.*, (SELECT COUNT(*) FROM merchants m WHERE m.business_id = b.id AND m.deleted_at IS NULL) AS merchant_count").
+		Order(sortCol + dir).
+		Order("b.id").
+		Limit(f.PageSize).
+		Offset((f.Page - 1) * f.PageSize).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]entity.BusinessListItem, len(rows))
+	for i, row := range rows {
+		items[i] = entity.BusinessListItem{
+			Business:      *row.BusinessModel.toEntity(),
+			MerchantCount: row.MerchantCount,
+		}
+	}
+	return items, total, nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/google/uuid"
+)
+
+// --- Business hierarchy ---
+
+// ListBusinessTree returns the live businesses under rootID, the root
+// included at depth 0, parents before their children. The path of each row
+// stops the walk at a business already visited, should a cycle exist.
+func (r *sqliteRepo) ListBusinessTree(ctx context.Context, rootID uuid.UUID) ([]entity.BusinessNode, error) {
+	var rows []struct {
+		BusinessModel
+		Depth         int
+		MerchantCount int64
+	}
+	err := r.conn(ctx).Raw(`
+		WITH RECURSIVE tree(id, depth, path) AS (
+			SELECT id, 0, '/' || id || '/' FROM businesses WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT b.id, tree.depth + 1, tree.path || b.id || '/' FROM businesses b
+			JOIN tree ON b.parent_id = tree.id
+			WHERE b.deleted_at IS NULL AND instr(tree.path, '/' || b.id || '/') = 0
+		)
+		SELECT b.*, tree.depth AS depth,
+			(SELECT COUNT(*) FROM merchants m WHERE m.business_id = b.id AND m.deleted_at IS NULL) AS merchant_count
+		FROM tree JOIN businesses b ON b.id = tree.id
+		ORDER BY tree.depth, b.created_at`, rootID).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]entity.BusinessNode, len(rows))
+	for i, row := range rows {
+		nodes[i] = entity.BusinessNode{
+			Business:      *row.BusinessModel.toEntity(),
+			Depth:         row.Depth,
+			MerchantCount: row.MerchantCount,
+		}
+	}
+	return nodes, nil
+}
+
+// rootsCTE maps every business, deleted ones included so past volume keeps
+// rolling up, to the top of its hierarchy. UNION drops the rows already
+// produced, so a cycle cannot loop.
+const rootsCTE = `WITH RECURSIVE roots(id, root) AS (
+	SELECT id, id FROM businesses WHERE parent_id IS NULL
+	UNION
+	SELECT b.id, roots.root FROM businesses b JOIN roots ON b.parent_id = roots.id
+)`
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// --- Merchant ownership ---
+
+// moveOwnership closes the merchant's current ownership at `at` and opens a
+// new one for business `to`. Must run inside a transaction.
+func moveOwnership(tx *gorm.DB, merchantID, to uuid.UUID, at time.Time) error {
+	at = at.UTC()
+	var current MerchantOwnershipModel
+	if err := tx.Where("merchant_id = ? AND valid_to IS NULL", merchantID).First(&current).Error; err != nil {
+		return err
+	}
+	if at.Before(current.ValidFrom) {
+		return &entity.ValidationError{Field: "effective_at", Reason: "is before the start of the current ownership (" + current.ValidFrom.Format(time.RFC3339) + ")"}
+	}
+	if err := tx.Model(&current).Update("valid_to", at).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(&MerchantOwnershipModel{ID: uuid.New(), MerchantID: merchantID, BusinessID: to, ValidFrom: at}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&MerchantModel{}).Where("id = ?", merchantID).
+		Updates(map[string]interface{}{"business_id": to, "updated_at": time.Now().UTC()}).Error
+}
+
+// backfillOwnership opens an ownership for every merchant, since its creation,
+// and attributes existing transactions to the merchant's current business
+func backfillOwnership(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var merchants []MerchantModel
+		if err := tx.Unscoped().Find(&merchants).Error; err != nil {
+			return err
+		}
+		for _, m := range merchants {
+			own := MerchantOwnershipModel{ID: uuid.New(), MerchantID: m.ID, BusinessID: m.BusinessID, ValidFrom: m.CreatedAt}
+			if err := tx.Create(&own).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec(`UPDATE transactions SET business_id =
+			(SELECT m.business_id FROM merchants m WHERE m.id = transactions.merchant_id)
+			WHERE business_id IS NULL`).Error
+	})
+}
+
+func (r *sqliteRepo) TransferMerchant(ctx context.Context, t *entity.MerchantTransfer) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := moveOwnership(tx, t.MerchantID, t.ToBusinessID, t.EffectiveAt); err != nil {
+			return err
+		}
+		res := tx.Model(&TransactionModel{}).
+			Where("merchant_id = ? AND timestamp >= ?", t.MerchantID, t.EffectiveAt.UTC()).
+			Update("business_id", t.ToBusinessID)
+		if res.Error != nil {
+			return res.Error
+		}
+		t.Reattributed = res.RowsAffected
+		return nil
+	})
+}
+
+func (r *sqliteRepo) ListMerchantOwnership(ctx context.Context, merchantID uuid.UUID) ([]entity.MerchantOwnership, error) {
+	var models []MerchantOwnershipModel
+	if err := r.conn(ctx).Where("merchant_id = ?", merchantID).Order("valid_from").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	history := make([]entity.MerchantOwnership, len(models))
+	for i, m := range models {
+		history[i] = *m.toEntity()
+	}
+	return history, nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/google/uuid"
+)
+
+// --- ReportRepository Implementation ---
+
+func (r *sqliteRepo) AggregateTransactions(ctx context.Context, group entity.ReportGroup, from, to time.Time) ([]entity.AggregateRow, error) {
+	// business_id is the owner at processing time, so volume stays with the
+	// business that owned the merchant back then
+	var rows []struct {
+		ID     uuid.UUID
+		Count  int64
+		Volume int64
+		Fees   int64
+	}
+	var err error
+	switch group {
+	case entity.GroupByRoot:
+		err = r.conn(ctx).Raw(rootsCTE+`
+			SELECT roots.root AS id, COUNT(t.id) AS count, COALESCE(SUM(t.amount),0) AS volume, COALESCE(SUM(t.fee),0) AS fees
+			FROM transactions t JOIN roots ON roots.id = t.business_id
+			WHERE t.deleted_at IS NULL AND t.timestamp >= ? AND t.timestamp < ?
+			GROUP BY roots.root`, from.UTC(), to.UTC()).
+			Scan(&rows).Error
+	default:
+		key := "merchant_id"
+		switch group {
+		case entity.GroupByBusiness:
+			key = "business_id"
+		case entity.GroupByTerminal:
+			key = "terminal_id"
+		}
+		err = r.conn(ctx).
+			Table("transactions").
+			Select(key+" AS id, COUNT(id) AS count, COALESCE(SUM(amount),0) AS volume, COALESCE(SUM(fee),0) AS fees").
+			Where("deleted_at IS NULL AND timestamp >= ? AND timestamp < ?", from.UTC(), to.UTC()).
+			Where(key + " IS NOT NULL").
+			Group(key).
+			Scan(&rows).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]entity.AggregateRow, len(rows))
+	for i, row := range rows {
+		out[i] = entity.AggregateRow{ID: row.ID, Count: row.Count, Volume: row.Volume, Fees: row.Fees}
+	}
+	return out, nil
+}
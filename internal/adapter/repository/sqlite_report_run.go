@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+)
+
+// --- ReportRunRepository Implementation ---
+
+func (r *sqliteRepo) CreateReportRun(ctx context.Context, run *entity.ReportRun) error {
+	return r.conn(ctx).Create(toReportRunModel(run)).Error
+}
+
+func (r *sqliteRepo) UpdateReportRun(ctx context.Context, run *entity.ReportRun) error {
+	return r.conn(ctx).Save(toReportRunModel(run)).Error
+}
+
+func (r *sqliteRepo) ListReportRuns(ctx context.Context, report entity.ScheduledReport, status entity.ReportRunStatus, limit int) ([]entity.ReportRun, error) {
+	q := r.conn(ctx).Order("started_at DESC").Limit(limit)
+	if report != "" {
+		q = q.Where("report = ?", string(report))
+	}
+	if status != "" {
+		q = q.Where("status = ?", string(status))
+	}
+
+	var models []ReportRunModel
+	if err := q.Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	runs := make([]entity.ReportRun, len(models))
+	for i, m := range models {
+		runs[i] = *m.toEntity()
+	}
+	return runs, nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- SummaryRepository Implementation ---
+
+// applyToSummary folds a single transaction into its merchant/day row.
+// Must run on the same *gorm.DB (transaction) as the write it summarizes.
+func applyToSummary(tx *gorm.DB, t *TransactionModel) error {
+	row := DailySummaryModel{
+		MerchantID: t.MerchantID,
+		Day:        entity.SummaryDay(t.Timestamp).Format(summaryDayLayout),
+		TxCount:    1,
+		Gross:      t.Amount,
+		Fees:       t.Fee,
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "merchant_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"tx_count": gorm.Expr("tx_count + ?", row.TxCount),
+			"gross":    gorm.Expr("gross + ?", row.Gross),
+			"fees":     gorm.Expr("fees + ?", row.Fees),
+		}),
+	}).Create(&row).Error
+}
+
+// applyRefundToSummary adds a refund to the merchant's row of the day it was
+// made, not the day of the refunded transaction, so closed days never change.
+// Same transaction rule as applyToSummary.
+func applyRefundToSummary(tx *gorm.DB, merchantID uuid.UUID, at time.Time, amount int64) error {
+	row := DailySummaryModel{
+		MerchantID: merchantID,
+		Day:        entity.SummaryDay(at).Format(summaryDayLayout),
+		Refunds:    amount,
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "merchant_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"refunds": gorm.Expr("refunds + ?", row.Refunds)}),
+	}).Create(&row).Error
+}
+
+// ListDailySummaries returns the rows of the days in [from, to), callers
+// asking for an inclusive last day pass the day after it
+func (r *sqliteRepo) ListDailySummaries(ctx context.Context, merchantID uuid.UUID, from, to time.Time) ([]entity.DailySummary, error) {
+	var models []DailySummaryModel
+	if err := r.conn(ctx).
+		Where("merchant_id = ? AND day >= ? AND day < ?", merchantID, from.UTC().Format(summaryDayLayout), to.UTC().Format(summaryDayLayout)).
+		Order("day").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	summaries := make([]entity.DailySummary, len(models))
+	for i, m := range models {
+		summaries[i] = *m.toEntity()
+	}
+	return summaries, nil
+}
+
+func (r *sqliteRepo) ListDailySummariesForDay(ctx context.Context, day time.Time) ([]entity.DailySummary, error) {
+	var models []DailySummaryModel
+	if err := r.conn(ctx).
+		Where("day = ?", entity.SummaryDay(day).Format(summaryDayLayout)).
+		Order("merchant_id").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	summaries := make([]entity.DailySummary, len(models))
+	for i, m := range models {
+		summaries[i] = *m.toEntity()
+	}
+	return summaries, nil
+}
+
+func (r *sqliteRepo) SumDailySummaries(ctx context.Context, merchantID *uuid.UUID) (entity.DailySummary, error) {
+	var total struct {
+		TxCount int64
+		Gross   int64
+		Fees    int64
+		Refunds int64
+	}
+	q := r.conn(ctx).Model(&DailySummaryModel{}).
+		Select("COALESCE(SUM(tx_count),0) AS tx_count, COALESCE(SUM(gross),0) AS gross, COALESCE(SUM(fees),0) AS fees, COALESCE(SUM(refunds),0) AS refunds")
+	if merchantID != nil {
+		q = q.Where("merchant_id = ?", *merchantID)
+	}
+	if err := q.Scan(&total).Error; err != nil {
+		return entity.DailySummary{}, err
+	}
+
+	sum := entity.DailySummary{Count: total.TxCount, Gross: total.Gross, Fees: total.Fees, Refunds: total.Refunds}
+	if merchantID != nil {
+		sum.MerchantID = *merchantID
+	}
+	return sum, nil
+}
+
+// RebuildDailySummaries recomputes the summary rows of every day touched by
+// [from, to) from the transactions table. Zero times mean "unbounded".
+func (r *sqliteRepo) RebuildDailySummaries(ctx context.Context, from, to time.Time) (int, error) {
+	rows := 0
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		del := tx.Where("1 = 1")
+		q := tx.Model(&TransactionModel{})
+		refunds := tx.Model(&TransactionModel{}).Where("refunded_at IS NOT NULL")
+		if !from.IsZero() {
+			from = entity.SummaryDay(from)
+			del = del.Where("day >= ?", from.Format(summaryDayLayout))
+			q = q.Where("timestamp >= ?", from.UTC())
+			refunds = refunds.Where("refunded_at >= ?", from.UTC())
+		}
+		if !to.IsZero() {
+			// widen to whole days so no partial day is left half summarized
+			if day := entity.SummaryDay(to); !day.Equal(to) {
+				to = day.AddDate(0, 0, 1)
+			}
+			del = del.Where("day < ?", to.Format(summaryDayLayout))
+			q = q.Where("timestamp < ?", to.UTC())
+			refunds = refunds.Where("refunded_at < ?", to.UTC())
+		}
+		if err := del.Delete(&DailySummaryModel{}).Error; err != nil {
+			return err
+		}
+
+		acc := map[[2]string]*DailySummaryModel{}
+		rowFor := func(merchantID uuid.UUID, at time.Time) *DailySummaryModel {
+			key := [2]string{merchantID.String(), entity.SummaryDay(at).Format(summaryDayLayout)}
+			row, ok := acc[key]
+			if !ok {
+				row = &DailySummaryModel{MerchantID: merchantID, Day: key[1]}
+				acc[key] = row
+			}
+			return row
+		}
+		var batch []TransactionModel
+		err := q.FindInBatches(&batch, 1000, func(_ *gorm.DB, _ int) error {
+			for _, t := range batch {
+				row := rowFor(t.MerchantID, t.Timestamp)
+				row.TxCount++
+				row.Gross += t.Amount
+				row.Fees += t.Fee
+			}
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+		err = refunds.FindInBatches(&batch, 1000, func(_ *gorm.DB, _ int) error {
+			for _, t := range batch {
+				rowFor(t.MerchantID, *t.RefundedAt).Refunds += t.Amount
+			}
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, row := range acc {
+			if err := tx.Create(row).Error; err != nil {
+				return err
+			}
+		}
+		rows = len(acc)
+		return nil
+	})
+	return rows, err
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// --- TerminalRepository Implementation ---
+
+func (r *sqliteRepo) CreateTerminal(ctx context.Context, t *entity.Terminal) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		if err := tx.Model(&TerminalModel{}).Where("serial_number = ?", t.SerialNumber).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return &entity.RejectedError{Code: entity.CodeDuplicateSerial, Reason: "serial number " + t.SerialNumber + " is already registered"}
+		}
+		return tx.Create(toTerminalModel(t)).Error
+	})
+}
+
+func (r *sqliteRepo) GetTerminalByID(ctx context.Context, id uuid.UUID) (*entity.Terminal, error) {
+	var model TerminalModel
+	if err := r.conn(ctx).First(&model, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return model.toEntity(), nil
+}
+
+func (r *sqliteRepo) ListMerchantTerminals(ctx context.Context, merchantID uuid.UUID) ([]entity.Terminal, error) {
+	var models []TerminalModel
+	if err := r.conn(ctx).Where("merchant_id = ?", merchantID).Order("created_at").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	terminals := make([]entity.Terminal, len(models))
+	for i, m := range models {
+		terminals[i] = *m.toEntity()
+	}
+	return terminals, nil
+}
+
+func (r *sqliteRepo) UpdateTerminal(ctx context.Context, t *entity.Terminal) error {
+	return r.conn(ctx).Save(toTerminalModel(t)).Error
+}
+
+func (r *sqliteRepo) TransactionListByTerminal(ctx context.Context, terminalID uuid.UUID) ([]entity.Transaction, error) {
+	var models []TransactionModel
+	if err := r.conn(ctx).Where("terminal_id = ?", terminalID).Order("timestamp").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	transactions := make([]entity.Transaction, len(models))
+	for i, m := range models {
+		transactions[i] = *m.toEntity()
+	}
+	return transactions, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// --- Timestamps ---
+
+// timeColumns lists the time columns of every table
+var timeColumns = []struct {
+	table   string
+	columns []string
+}{
+	{"businesses", []string{"status_changed_at", "created_at", "updated_at", "deleted_at"}},
+	{"merchants", []string{"status_changed_at", "commission_override_expires_at", "created_at", "updated_at", "deleted_at"}},
+	{"merchant_ownerships", []string{"valid_from", "valid_to"}},
+	{"terminals", []string{"status_changed_at", "last_seen_at", "created_at", "updated_at"}},
+	{"transactions", []string{"timestamp", "refunded_at", "deleted_at"}},
+	{"report_runs", []string{"scheduled_for", "period_start", "period_end", "started_at", "finished_at"}},
+	{"audit_logs", []string{"timestamp"}},
+	{"audit_anchors", []string{"created_at"}},
+	{"audit_archives", []string{"from", "to", "created_at"}},
+	{"access_logs", []string{"timestamp"}},
+}
+
+// normalizeTimestamps rewrites in UTC the times written in the server's zone
+// by older versions. Nothing is left to rewrite after the first boot.
+func normalizeTimestamps(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, t := range timeColumns {
+			for _, col := range t.columns {
+				if err := normalizeColumn(tx, t.table, col); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func normalizeColumn(tx *gorm.DB, table, col string) error {
+	rows, err := tx.Raw(`SELECT rowid, "` + col + `" FROM ` + table +
+		` WHERE "` + col + `" IS NOT NULL AND "` + col + `" NOT LIKE '%+00:00'`).Rows()
+	if err != nil {
+		return err
+	}
+	type stale struct {
+		rowid int64
+		at    time.Time
+	}
+	var found []stale
+	for rows.Next() {
+		var s stale
+		if err := rows.Scan(&s.rowid, &s.at); err != nil {
+			rows.Close()
+			return err
+		}
+		found = append(found, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, s := range found {
+		if err := tx.Exec(`UPDATE `+table+` SET "`+col+`" = ? WHERE rowid = ?`, s.at.UTC(), s.rowid).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// --- UnitOfWork Implementation ---
+
+type txKey struct{}
+
+// conn is the handle every repository method runs on: the unit of work's
+// transaction when ctx carries one, the pool otherwise
+func (r *sqliteRepo) conn(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return r.db.WithContext(ctx)
+}
+
+func inUnitOfWork(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return ok
+}
+
+// Do runs fn in one database transaction, committed when fn returns nil.
+// Units of work may append to the audit chain, so chainMu is held until
+// the commit, the audit feed is woken after it. A nested Do joins the outer
+// transaction.
+func (r *sqliteRepo) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if inUnitOfWork(ctx) {
+		return fn(ctx)
+	}
+	logged := false
+	err := func() error {
+		chainMu.Lock()
+		defer chainMu.Unlock()
+		return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			ctx := context.WithValue(context.WithValue(ctx, txKey{}, tx), loggedKey{}, &logged)
+			return fn(ctx)
+		})
+	}()
+	if err == nil && logged {
+		logFeed.notify()
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// JobFunc runs one attempt of a job. scheduledFor is the cron activation
+// being served, it stays the same across retries of that activation.
+type JobFunc func(ctx context.Context, scheduledFor time.Time, attempt int) error
+
+type job struct {
+	name     string
+	schedule *Schedule
+	fn       JobFunc
+}
+
+// Scheduler is an in-process cron. Each job runs in its own goroutine, so a
+// slow job only delays its own next activation.
+type Scheduler struct {
+	jobs       []*job
+	maxRetries int
+	retryDelay time.Duration
+}
+
+// New creates a scheduler retrying failed attempts up to maxRetries times,
+// waiting retryDelay, then doubling it, between attempts
+func New(maxRetries int, retryDelay time.Duration) *Scheduler {
+	return &Scheduler{maxRetries: maxRetries, retryDelay: retryDelay}
+}
+
+// Add registers a job, an empty spec leaves the job disabled
+func (s *Scheduler) Add(name, spec string, fn JobFunc) error {
+	if spec == "" {
+		return nil
+	}
+	sched, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	s.jobs = append(s.jobs, &job{name: name, schedule: sched, fn: fn})
+	return nil
+}
+
+// Start runs the registered jobs until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		go s.loop(ctx, j)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("[SCHEDULER] %s never fires, disabled", j.name)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.run(ctx, j, next)
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, j *job, scheduledFor time.Time) {
+	delay := s.retryDelay
+	for attempt := 1; ; attempt++ {
+		err := j.fn(ctx, scheduledFor, attempt)
+		if err == nil {
+			return
+		}
+		if attempt > s.maxRetries {
+			log.Printf("[SCHEDULER] %s (%s) failed after %d attempts: %v", j.name, scheduledFor.Format(time.RFC3339), attempt, err)
+			return
+		}
+		log.Printf("[SCHEDULER] %s attempt %d failed, retrying in %s: %v", j.name, attempt, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
@@ -1,6 +1,10 @@
 package entity
 
 import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,9 +12,100 @@ import (
 
 type Log struct {
 	ID             uuid.UUID
-	Action         string //"UPDATE_TRANSACTION"
-	Actor          string // made by
-	ResourceID     string // ID of the affected resource
-	PrevResourceID string // Previous state
+	Action         AuditAction     // see the catalog in audit_action.go
+	Actor          string          // made by
+	ResourceID     string          // ID of the affected resource
+	ResourceType   ResourceType    // what ResourceID points to
+	LegacyAction   string          // action as first written, for entries older than the catalog
+	PrevResourceID string          // free text context, e.g. "policy:cascade"
+	Before         json.RawMessage // resource before the change, null on creation
+	After          json.RawMessage // resource after the change
+	Diff           []FieldChange   // computed from Before and After
 	Timestamp      time.Time
+	Sequence       int64  // position in the hash chain, starts at 1
+	PrevHash       string // Hash of the entry at Sequence-1, empty for the first one
+	Hash           string // see ComputeHash
+}
+
+// FieldChange is one leaf that differs between two snapshots, nested fields
+// are joined with dots (e.g. "Address.City")
+type FieldChange struct {
+	Field  string
+	Before json.RawMessage
+	After  json.RawMessage
+}
+
+// Snapshot serializes a resource for the audit trail, nil (or a nil pointer)
+// gives an empty snapshot
+func Snapshot(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// Diff lists the fields that differ between two snapshots, sorted by field.
+// Objects are compared field by field, anything else (arrays included) as a
+// whole value. Without a before snapshot (a creation) the fields left at
+// their zero value are skipped.
+func Diff(before, after json.RawMessage) []FieldChange {
+	b, a := map[string]json.RawMessage{}, map[string]json.RawMessage{}
+	flatten("", before, b)
+	flatten("", after, a)
+	creation := len(b) == 0
+
+	var changes []FieldChange
+	for field, bv := range b {
+		if av, ok := a[field]; !ok || !bytes.Equal(bv, av) {
+			changes = append(changes, FieldChange{Field: field, Before: bv, After: a[field]})
+		}
+	}
+	for field, av := range a {
+		if _, ok := b[field]; !ok && !(creation && isZeroJSON(av)) {
+			changes = append(changes, FieldChange{Field: field, After: av})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// isZeroJSON tells whether a compacted JSON value is its type's zero value
+func isZeroJSON(v json.RawMessage) bool {
+	switch string(v) {
+	case "null", `""`, "0", "false", "[]":
+		return true
+	}
+	return false
+}
+
+func flatten(prefix string, raw json.RawMessage, out map[string]json.RawMessage) {
+	if len(raw) == 0 || string(raw) == "null" {
+		if prefix != "" {
+			out[prefix] = json.RawMessage("null")
+		}
+		return
+	}
+	var obj map[string]json.RawMessage
+	if raw[0] == '{' && json.Unmarshal(raw, &obj) == nil {
+		for k, v := range obj {
+			if prefix != "" {
+				k = prefix + "." + k
+			}
+			flatten(k, v, out)
+		}
+		return
+	}
+	var buf bytes.Buffer
+	if json.Compact(&buf, raw) != nil {
+		buf.Reset()
+		buf.Write(raw)
+	}
+	out[prefix] = buf.Bytes()
 }
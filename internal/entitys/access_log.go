@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccessLog records a read of sensitive data: who asked, for which route and
+// resource and with which query. Kept apart from the audit chain, with its
+// own retention.
+type AccessLog struct {
+	ID         uuid.UUID
+	Actor      string
+	Method     string
+	Route      string // route pattern, e.g. /api/v1/transactions/:id
+	Path       string
+	ResourceID string // first path parameter, empty for listings
+	Query      map[string][]string
+	Status     int
+	Timestamp  time.Time
+}
+
+// AccessLogFilter narrows an access log search, empty fields match everything
+type AccessLogFilter struct {
+	Actor      string
+	ResourceID string
+	Route      string
+	From       *time.Time
+	To         *time.Time // exclusive
+	Limit      int
+}
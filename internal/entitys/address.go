@@ -0,0 +1,34 @@
+package entity
+
+type Address struct {
+	Street         string
+	ExteriorNumber string
+	InteriorNumber string
+	Neighborhood   string // Colonia
+	City           string
+	State          string
+	PostalCode     string
+	Country        string // ISO 3166-1 alpha-2, e.g. "MX"
+}
+
+func (a Address) Validate() error {
+	if a.PostalCode != "" && !isDigits(a.PostalCode, 5) {
+		return &ValidationError{Field: "address.postal_code", Reason: "must be 5 digits"}
+	}
+	if a.Country != "" && (len(a.Country) != 2 || a.Country[0] < 'A' || a.Country[0] > 'Z' || a.Country[1] < 'A' || a.Country[1] > 'Z') {
+		return &ValidationError{Field: "address.country", Reason: "must be an ISO 3166-1 alpha-2 code"}
+	}
+	return nil
+}
+
+func isDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditSource identifies the audit entry a historical state was rebuilt from
+type AuditSource struct {
+	LogID     uuid.UUID
+	Sequence  int64
+	Action    AuditAction
+	Actor     string
+	Timestamp time.Time
+	Archived  bool // read from an archive file
+}
+
+func NewAuditSource(l *Log, archived bool) AuditSource {
+	return AuditSource{LogID: l.ID, Sequence: l.Sequence, Action: l.Action, Actor: l.Actor, Timestamp: l.Timestamp, Archived: archived}
+}
+
+// HistoricalBusiness is a business as it was at AsOf, the After snapshot of
+// the last audit entry written for it up to then
+type HistoricalBusiness struct {
+	Business *Business
+	AsOf     time.Time
+	Source   AuditSource
+}
+
+// HistoricalMerchant is a merchant as it was at AsOf, see HistoricalBusiness
+type HistoricalMerchant struct {
+	Merchant *Merchant
+	AsOf     time.Time
+	Source   AuditSource
+}
@@ -0,0 +1,127 @@
+package entity
+
+// AuditAction is an entry of the audit action catalog, named
+// <RESOURCE>_<WHAT HAPPENED>
+type AuditAction string
+
+const (
+	ActionBusinessRegistered        AuditAction = "BUSINESS_REGISTERED"
+	ActionBusinessProfileUpdated    AuditAction = "BUSINESS_PROFILE_UPDATED"
+	ActionBusinessCommissionUpdated AuditAction = "BUSINESS_COMMISSION_UPDATED"
+	ActionBusinessParentUpdated     AuditAction = "BUSINESS_PARENT_UPDATED"
+	ActionBusinessStatusChanged     AuditAction = "BUSINESS_STATUS_CHANGED"
+	ActionBusinessDeleted           AuditAction = "BUSINESS_DELETED"
+	ActionBusinessRestored          AuditAction = "BUSINESS_RESTORED"
+	ActionBusinessInvoiced          AuditAction = "BUSINESS_INVOICED"
+
+	ActionMerchantRegistered        AuditAction = "MERCHANT_REGISTERED"
+	ActionMerchantProfileUpdated    AuditAction = "MERCHANT_PROFILE_UPDATED"
+	ActionMerchantCommissionUpdated AuditAction = "MERCHANT_COMMISSION_UPDATED"
+	ActionMerchantStatusChanged     AuditAction = "MERCHANT_STATUS_CHANGED"
+	ActionMerchantTransferred       AuditAction = "MERCHANT_TRANSFERRED"
+	ActionMerchantReassigned        AuditAction = "MERCHANT_REASSIGNED"
+	ActionMerchantDeleted           AuditAction = "MERCHANT_DELETED"
+	ActionMerchantRestored          AuditAction = "MERCHANT_RESTORED"
+
+	ActionTransactionCreated  AuditAction = "TRANSACTION_CREATED"
+	ActionTransactionRefunded AuditAction = "TRANSACTION_REFUNDED"
+
+	ActionTerminalRegistered  AuditAction = "TERMINAL_REGISTERED"
+	ActionTerminalDeactivated AuditAction = "TERMINAL_DEACTIVATED"
+)
+
+// ResourceType is the kind of resource a Log.ResourceID points to
+type ResourceType string
+
+const (
+	ResourceBusiness    ResourceType = "business"
+	ResourceMerchant    ResourceType = "merchant"
+	ResourceTransaction ResourceType = "transaction"
+	ResourceTerminal    ResourceType = "terminal"
+)
+
+// actionCatalog maps every action to the type of its ResourceID
+var actionCatalog = map[AuditAction]ResourceType{
+	ActionBusinessRegistered:        ResourceBusiness,
+	ActionBusinessProfileUpdated:    ResourceBusiness,
+	ActionBusinessCommissionUpdated: ResourceBusiness,
+	ActionBusinessParentUpdated:     ResourceBusiness,
+	ActionBusinessStatusChanged:     ResourceBusiness,
+	ActionBusinessDeleted:           ResourceBusiness,
+	ActionBusinessRestored:          ResourceBusiness,
+	ActionBusinessInvoiced:          ResourceBusiness,
+	ActionMerchantRegistered:        ResourceMerchant,
+	ActionMerchantProfileUpdated:    ResourceMerchant,
+	ActionMerchantCommissionUpdated: ResourceMerchant,
+	ActionMerchantStatusChanged:     ResourceMerchant,
+	ActionMerchantTransferred:       ResourceMerchant,
+	ActionMerchantReassigned:        ResourceMerchant,
+	ActionMerchantDeleted:           ResourceMerchant,
+	ActionMerchantRestored:          ResourceMerchant,
+	ActionTransactionCreated:        ResourceTransaction,
+	ActionTransactionRefunded:       ResourceTransaction,
+	ActionTerminalRegistered:        ResourceTerminal,
+	ActionTerminalDeactivated:       ResourceTerminal,
+}
+
+// legacyActions are the free form names written before the catalog
+var legacyActions = map[string]AuditAction{
+	"CREATE_BUSINESS":             ActionBusinessRegistered,
+	"UPDATE_BUSINESS_PROFILE":     ActionBusinessProfileUpdated,
+	"UPDATE_BUSINESS_FISCAL_DATA": ActionBusinessProfileUpdated,
+	"UPDATE_BUSINESS_COMMISSION":  ActionBusinessCommissionUpdated,
+	"UPDATE_BUSINESS_PARENT":      ActionBusinessParentUpdated,
+	"DELETE_BUSINESS":             ActionBusinessDeleted,
+	"RESTORE_BUSINESS":            ActionBusinessRestored,
+	"INVOICE_GENERATED":           ActionBusinessInvoiced,
+	"UPDATE_MERCHANT_PROFILE":     ActionMerchantProfileUpdated,
+	"UPDATE_MERCHANT_COMMISSION":  ActionMerchantCommissionUpdated,
+}
+
+// SnapshotsResource tells whether the After snapshot of the action is the
+// resource itself, invoices snapshot the invoice
+func (a AuditAction) SnapshotsResource() bool {
+	return a.Valid() && a != ActionBusinessInvoiced
+}
+
+func (a AuditAction) Valid() bool {
+	_, ok := actionCatalog[a]
+	return ok
+}
+
+// ResourceType is the type of the resources the action is recorded against
+func (a AuditAction) ResourceType() ResourceType {
+	return actionCatalog[a]
+}
+
+// NormalizeAction maps a stored action name, from the catalog or a legacy
+// one, to the catalog
+func NormalizeAction(name string) (AuditAction, bool) {
+	if a := AuditAction(name); a.Valid() {
+		return a, true
+	}
+	a, ok := legacyActions[name]
+	return a, ok
+}
+
+func (t ResourceType) Valid() bool {
+	switch t {
+	case ResourceBusiness, ResourceMerchant, ResourceTransaction, ResourceTerminal:
+		return true
+	}
+	return false
+}
+
+// Normalize brings an entry written before the catalog to it, keeping the
+// original name in LegacyAction (its hash was computed with it). Entries
+// already typed, or with an unknown action, are left as they are.
+func (l *Log) Normalize() {
+	if l.ResourceType != "" || l.LegacyAction != "" {
+		return
+	}
+	if a, ok := NormalizeAction(string(l.Action)); ok {
+		l.LegacyAction = string(l.Action)
+		l.Action = a
+		l.ResourceType = a.ResourceType()
+	}
+}
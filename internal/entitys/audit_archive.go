@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditArchive is a file holding audit entries moved out of the database by
+// the retention job, a contiguous run of the chain from FirstSequence to
+// LastSequence
+type AuditArchive struct {
+	ID            uuid.UUID
+	File          string // name inside the archive directory
+	FirstSequence int64
+	LastSequence  int64
+	From          time.Time // Timestamp of the oldest entry
+	To            time.Time // Timestamp of the newest entry
+	Entries       int
+	SHA256        string // of the compressed file
+	CreatedAt     time.Time
+}
+
+// Covers tells whether entries between from and to (exclusive), nil meaning
+// unbounded, may be in the archive
+func (a *AuditArchive) Covers(from, to *time.Time) bool {
+	if from != nil && a.To.Before(*from) {
+		return false
+	}
+	if to != nil && !a.From.Before(*to) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BundleManifest describes a signed audit export. The bundle signature covers
+// the manifest bytes, which in turn carry the checksum of the entries.
+type BundleManifest struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	ExportedBy    string
+	From          *time.Time // requested range, To exclusive
+	To            *time.Time
+	ResourceID    string
+	Entries       int
+	FirstSequence int64
+	LastSequence  int64
+	Contiguous    bool   // no sequence missing between First and Last, links can be checked
+	EntriesSHA256 string // of the NDJSON file
+	PublicKey     string // base64 Ed25519 key that signed the bundle
+}
@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ComputeHash is the SHA-256 of the entry content chained to PrevHash, any
+// edit of a stored entry (or of the one before it) changes the result.
+// Entries older than the action catalog are hashed as first written, with
+// their LegacyAction and no ResourceType.
+func (l *Log) ComputeHash() string {
+	action, resourceType := string(l.Action), l.ResourceType
+	if l.LegacyAction != "" {
+		action, resourceType = l.LegacyAction, ""
+	}
+	content, _ := json.Marshal(struct {
+		PrevHash       string
+		Sequence       int64
+		ID             uuid.UUID
+		Action         string
+		Actor          string
+		ResourceID     string
+		PrevResourceID string
+		Before         json.RawMessage
+		After          json.RawMessage
+		Timestamp      string
+		ResourceType   ResourceType `json:",omitempty"`
+	}{
+		l.PrevHash, l.Sequence, l.ID, action, l.Actor, l.ResourceID, l.PrevResourceID,
+		l.Before, l.After, l.Timestamp.UTC().Format(time.RFC3339Nano), resourceType,
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditAnchor is a checkpoint of the chain head. Kept outside the database it
+// proves that nothing up to Sequence was rewritten or truncated.
+type AuditAnchor struct {
+	Sequence  int64
+	Hash      string
+	CreatedAt time.Time
+}
+
+// ChainVerification is the result of walking the audit chain
+type ChainVerification struct {
+	Valid        bool
+	Checked      int64  // entries walked
+	LastSequence int64  // last valid entry
+	LastHash     string // hash of the last valid entry
+	Anchors      int    // anchors checked
+	Archives     int    // archive files walked before the database
+	Broken       *ChainBreak
+}
+
+// ChainBreak is the first broken link of the chain
+type ChainBreak struct {
+	Sequence int64
+	LogID    *uuid.UUID // nil when the entry is missing
+	Reason   string
+}
@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+// LogFilter drives the audit search, zero fields do not filter
+type LogFilter struct {
+	Actor        string
+	Action       AuditAction
+	ResourceID   string
+	ResourceType ResourceType
+	From         *time.Time
+	To           *time.Time // exclusive
+	Before       int64      // only entries with a lower Sequence, the page cursor
+	Limit        int
+	Archived     bool // also search the archives, implied by a From older than the retention
+}
+
+// Match applies the filter to a single entry, as the repository query does
+func (f *LogFilter) Match(l *Log) bool {
+	switch {
+	case f.Actor != "" && l.Actor != f.Actor,
+		f.Action != "" && l.Action != f.Action,
+		f.ResourceID != "" && l.ResourceID != f.ResourceID,
+		f.ResourceType != "" && l.ResourceType != f.ResourceType,
+		f.From != nil && l.Timestamp.Before(*f.From),
+		f.To != nil && !l.Timestamp.Before(*f.To),
+		f.Before > 0 && l.Sequence >= f.Before:
+		return false
+	}
+	return true
+}
+
+// LogPage is one page of the audit search, newest entries first. Pass
+// NextCursor back as the cursor to read the following page, it is empty on
+// the last one.
+type LogPage struct {
+	Entries    []Log
+	NextCursor string
+}
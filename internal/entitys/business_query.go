@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+type DeletionFilter string
+
+const (
+	OnlyActive  DeletionFilter = "active" // default
+	OnlyDeleted DeletionFilter = "deleted"
+	AnyDeletion DeletionFilter = "all"
+)
+
+// BusinessFilter drives the business listing, nil/zero fields do not filter
+type BusinessFilter struct {
+	MinCommission *int64 // basis points, inclusive
+	MaxCommission *int64 // basis points, inclusive
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time // exclusive
+	Deletion      DeletionFilter
+	SortBy        string // created_at, updated_at, commission, legal_name, merchant_count
+	Desc          bool
+	Page          int // 1-based
+	PageSize      int
+}
+
+type BusinessListItem struct {
+	Business
+	MerchantCount int64 // Active (not deleted) merchants
+}
+
+type BusinessPage struct {
+	Items    []BusinessListItem
+	Page     int
+	PageSize int
+	Total    int64
+}
@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"net/mail"
+	"regexp"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,16 +10,137 @@ import (
 
 type Business struct {
 	ID         uuid.UUID
-	Commission int64 // Represented in basis points (e.g., 550 = 5.5%)
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-	DeletedAt  *time.Time
+	ParentID   *uuid.UUID // Business group this one belongs to
+	Commission int64      // Represented in basis points (e.g., 550 = 5.5%)
+	BusinessProfile
+	Status BusinessStatus
+	StatusChange
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+}
+
+// FiscalData is what the SAT needs to invoice a business (CFDI Receptor)
+type FiscalData struct {
+	LegalName  string
+	RFC        string
+	TaxRegime  string // SAT c_RegimenFiscal (e.g., "601")
+	PostalCode string // Fiscal address postal code
+}
+
+// Complete reports whether the business can be invoiced
+func (f FiscalData) Complete() bool {
+	return f.LegalName != "" && f.RFC != "" && f.TaxRegime != "" && f.PostalCode != ""
+}
+
+// SAT c_RegimenFiscal catalog
+var taxRegimes = map[string]bool{
+	"601": true, "603": true, "605": true, "606": true, "607": true, "608": true,
+	"610": true, "611": true, "612": true, "614": true, "615": true, "616": true,
+	"620": true, "621": true, "622": true, "623": true, "624": true, "625": true,
+	"626": true,
+}
+
+// Validate checks the fields that are set, empty ones are allowed
+func (f FiscalData) Validate() error {
+	if f.RFC != "" {
+		if err := ValidateRFC(f.RFC); err != nil {
+			return err
+		}
+	}
+	if f.TaxRegime != "" && !taxRegimes[f.TaxRegime] {
+		return &ValidationError{Field: "tax_regime", Reason: "not in the SAT c_RegimenFiscal catalog"}
+	}
+	if f.PostalCode != "" && !isDigits(f.PostalCode, 5) {
+		return &ValidationError{Field: "postal_code", Reason: "must be 5 digits"}
+	}
+	return nil
+}
+
+// BusinessProfile is the descriptive data of a business
+type BusinessProfile struct {
+	FiscalData
+	TradeName    string
+	ContactEmail string
+	Phone        string // digits only, optional leading '+'
+	Address      Address
+}
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{10,15}$`)
+
+func (p BusinessProfile) Validate() error {
+	if err := p.FiscalData.Validate(); err != nil {
+		return err
+	}
+	if err := validateContact(p.ContactEmail, p.Phone); err != nil {
+		return err
+	}
+	return p.Address.Validate()
+}
+
+// validateContact checks the contact fields that are set
+func validateContact(email, phone string) error {
+	if email != "" {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return &ValidationError{Field: "contact_email", Reason: "invalid email address"}
+		}
+	}
+	if phone != "" && !phonePattern.MatchString(phone) {
+		return &ValidationError{Field: "phone", Reason: "must be 10 to 15 digits"}
+	}
+	return nil
+}
+
+// BusinessProfilePatch carries a partial profile update, nil means unchanged
+type BusinessProfilePatch struct {
+	LegalName    *string
+	TradeName    *string
+	RFC          *string
+	TaxRegime    *string
+	PostalCode   *string
+	ContactEmail *string
+	Phone        *string
+	Address      *Address
 }
 
 type Merchant struct {
 	ID         uuid.UUID
 	BusinessID uuid.UUID
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-	DeletedAt  *time.Time
+	MerchantProfile
+	CommissionOverride *CommissionOverride
+	Status             MerchantStatus
+	StatusChange
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+}
+
+// MerchantProfile is the descriptive data of a merchant
+type MerchantProfile struct {
+	DisplayName  string
+	MCC          string // ISO 18245 merchant category code, e.g. "5812"
+	Location     Address
+	ContactEmail string
+	Phone        string // digits only, optional leading '+'
+}
+
+func (p MerchantProfile) Validate() error {
+	if p.MCC != "" {
+		if _, ok := LookupMCC(p.MCC); !ok {
+			return &ValidationError{Field: "mcc", Reason: "unknown merchant category code"}
+		}
+	}
+	if err := validateContact(p.ContactEmail, p.Phone); err != nil {
+		return err
+	}
+	return p.Location.Validate()
+}
+
+// MerchantProfilePatch carries a partial profile update, nil means unchanged
+type MerchantProfilePatch struct {
+	DisplayName  *string
+	MCC          *string
+	Location     *Address
+	ContactEmail *string
+	Phone        *string
 }
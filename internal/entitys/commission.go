@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+// CommissionSource tells where the rate charged on a transaction came from
+type CommissionSource string
+
+const (
+	SourceBusiness         CommissionSource = "business"
+	SourceMerchantOverride CommissionSource = "merchant_override"
+)
+
+// CommissionOverride is a merchant specific rate, ExpiresAt nil means it
+// never expires
+type CommissionOverride struct {
+	Rate      int64 // basis points
+	ExpiresAt *time.Time
+}
+
+func (o *CommissionOverride) ActiveAt(t time.Time) bool {
+	return o != nil && (o.ExpiresAt == nil || t.Before(*o.ExpiresAt))
+}
+
+// EffectiveCommission resolves the rate charged to the merchant at t: its
+// override while active, otherwise the business rate
+func (m Merchant) EffectiveCommission(biz Business, t time.Time) (int64, CommissionSource) {
+	if m.CommissionOverride.ActiveAt(t) {
+		return m.CommissionOverride.Rate, SourceMerchantOverride
+	}
+	return biz.Commission, SourceBusiness
+}
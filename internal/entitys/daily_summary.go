@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DailySummary aggregates a merchant's transactions for one UTC day
+type DailySummary struct {
+	MerchantID uuid.UUID
+	Day        time.Time // 00:00 UTC of the summarized day
+	Count      int64
+	Gross      int64 // Sum of Amount, in cents
+	Fees       int64 // Sum of Fee, in cents
+	Refunds    int64 // Refunded amount, in cents
+}
+
+// SummaryDay truncates a timestamp to the UTC day used as summary key
+func SummaryDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
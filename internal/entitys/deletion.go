@@ -0,0 +1,24 @@
+package entity
+
+import "github.com/google/uuid"
+
+// DeletionPolicy decides what happens to the merchants of a deleted business
+type DeletionPolicy string
+
+const (
+	DeleteBlock    DeletionPolicy = "block"    // refuse while merchants exist
+	DeleteCascade  DeletionPolicy = "cascade"  // soft delete the merchants too
+	DeleteReassign DeletionPolicy = "reassign" // move the merchants to another business
+)
+
+func (p DeletionPolicy) Valid() bool {
+	return p == DeleteBlock || p == DeleteCascade || p == DeleteReassign
+}
+
+// BusinessDeletion describes a business deletion and the merchants it touched
+type BusinessDeletion struct {
+	BusinessID       uuid.UUID
+	Policy           DeletionPolicy
+	TargetBusinessID *uuid.UUID
+	Merchants        []uuid.UUID // deleted or reassigned merchants
+}
@@ -0,0 +1,52 @@
+package entity
+
+// ValidationError reports input rejected by a domain rule
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+// NotFoundError reports a resource that does not exist, told apart from
+// storage failures
+type NotFoundError struct {
+	Resource string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Resource + " not found"
+}
+
+// RejectedError is an operation refused by the current state of a resource,
+// Code is stable so clients can branch on it
+type RejectedError struct {
+	Code   string
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return e.Code + ": " + e.Reason
+}
+
+// Rejection codes returned to API clients
+const (
+	CodeMerchantNotActive       = "MERCHANT_NOT_ACTIVE"
+	CodeBusinessSuspended       = "BUSINESS_SUSPENDED"
+	CodeInvalidStatusTransition = "INVALID_STATUS_TRANSITION"
+	CodeBusinessHasMerchants    = "BUSINESS_HAS_MERCHANTS"
+	CodeNotDeleted              = "NOT_DELETED"
+	CodeParentDeleted           = "PARENT_DELETED"
+	CodeHierarchyCycle          = "HIERARCHY_CYCLE"
+	CodeBusinessHasChildren     = "BUSINESS_HAS_CHILDREN"
+	CodeTerminalNotActive       = "TERMINAL_NOT_ACTIVE"
+	CodeDuplicateSerial         = "DUPLICATE_SERIAL_NUMBER"
+	CodeExportDisabled          = "AUDIT_EXPORT_DISABLED"
+	CodeHistoryUnavailable      = "HISTORY_UNAVAILABLE"
+	CodeFiscalDataIncomplete    = "FISCAL_DATA_INCOMPLETE"
+	CodeNothingToInvoice        = "NOTHING_TO_INVOICE"
+	CodeNegativeInvoice         = "NEGATIVE_INVOICE_AMOUNT"
+	CodeAlreadyRefunded         = "ALREADY_REFUNDED"
+)
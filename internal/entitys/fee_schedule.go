@@ -0,0 +1,47 @@
+package entity
+
+import "github.com/google/uuid"
+
+// FeeTier applies Rate to transactions of at least MinAmount
+type FeeTier struct {
+	MinAmount int64 // cents
+	Rate      int64 // basis points
+}
+
+// FeeSchedule is a commission proposal: a base rate plus optional
+// amount tiers, the highest tier whose MinAmount is reached wins
+type FeeSchedule struct {
+	Rate  int64 // basis points
+	Tiers []FeeTier
+}
+
+// RateFor returns the basis points the schedule charges for an amount
+func (s FeeSchedule) RateFor(amount int64) int64 {
+	rate := s.Rate
+	best := int64(-1)
+	for _, t := range s.Tiers {
+		if amount >= t.MinAmount && t.MinAmount > best {
+			rate, best = t.Rate, t.MinAmount
+		}
+	}
+	return rate
+}
+
+// SimulationMonth compares actual and simulated fee revenue for a month
+type SimulationMonth struct {
+	Month         string // YYYY-MM
+	Count         int64
+	Overridden    int64 // transactions priced by a merchant override, simulated at the fee they were charged
+	Volume        int64 // cents
+	CurrentFees   int64 // cents actually charged
+	SimulatedFees int64 // cents under the proposed schedule
+	Difference    int64 // SimulatedFees - CurrentFees
+}
+
+type CommissionSimulation struct {
+	BusinessID        uuid.UUID
+	CurrentCommission int64 // basis points
+	Proposed          FeeSchedule
+	Months            []SimulationMonth
+	Total             SimulationMonth // Month is empty
+}
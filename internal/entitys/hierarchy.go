@@ -0,0 +1,38 @@
+package entity
+
+import "github.com/google/uuid"
+
+// BusinessNode is a business inside a hierarchy, Depth 0 is the queried root
+type BusinessNode struct {
+	Business
+	Depth         int
+	MerchantCount int64 // Active (not deleted) merchants
+}
+
+// RollupTotals are the figures of a business over a period
+type RollupTotals struct {
+	Merchants int64
+	Count     int64
+	Volume    int64 // cents
+	Fees      int64 // cents
+}
+
+func (t *RollupTotals) Add(o RollupTotals) {
+	t.Merchants += o.Merchants
+	t.Count += o.Count
+	t.Volume += o.Volume
+	t.Fees += o.Fees
+}
+
+// BusinessRollup is one node of a consolidated report, Total is Own plus the
+// Total of every child
+type BusinessRollup struct {
+	BusinessID uuid.UUID
+	ParentID   *uuid.UUID
+	LegalName  string
+	TradeName  string
+	Depth      int
+	Own        RollupTotals
+	Total      RollupTotals
+	Children   []*BusinessRollup
+}
@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InvoiceIssuer is our own fiscal identity (CFDI Emisor)
+type InvoiceIssuer struct {
+	RFC         string
+	Name        string
+	TaxRegime   string
+	PostalCode  string // LugarExpedicion
+	CertNumber  string // NoCertificado of the CSD used for the Sello
+	Series      string
+	ProductCode string // SAT c_ClaveProdServ used for commission concepts
+}
+
+type InvoiceConcept struct {
+	MerchantID       uuid.UUID
+	Description      string
+	TransactionCount int
+	Amount           int64 // Commission charged in cents (ValorUnitario/Importe)
+	Tax              int64 // IVA transferred in cents
+}
+
+// InvoiceStamp is the fiscal seal returned by a PAC (TimbreFiscalDigital)
+type InvoiceStamp struct {
+	UUID      string
+	StampedAt time.Time
+	PAC       string
+}
+
+type Invoice struct {
+	BusinessID  uuid.UUID
+	Folio       string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	IssuedAt    time.Time
+	Issuer      InvoiceIssuer
+	Receiver    Business
+	Concepts    []InvoiceConcept
+	Subtotal    int64 // cents
+	Tax         int64 // cents (IVA 16%)
+	Total       int64 // cents
+	XML         []byte
+	Stamp       *InvoiceStamp // nil until a PAC stamps the document
+}
@@ -0,0 +1,75 @@
+package entity
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"sort"
+	"strings"
+)
+
+// MCC is an ISO 18245 merchant category code
+type MCC struct {
+	Code        string
+	Description string
+}
+
+//go:embed mcc_codes.csv
+var mccCSV string
+
+var mccTable = loadMCCTable()
+
+func loadMCCTable() map[string]MCC {
+	records, err := csv.NewReader(strings.NewReader(mccCSV)).ReadAll()
+	if err != nil {
+		panic("entity: invalid bundled MCC table: " + err.Error())
+	}
+	table := make(map[string]MCC, len(records))
+	for _, r := range records[1:] { // skip header
+		table[r[0]] = MCC{Code: r[0], Description: r[1]}
+	}
+	return table
+}
+
+// LookupMCC finds a code in the bundled MCC table
+func LookupMCC(code string) (MCC, bool) {
+	m, ok := mccTable[code]
+	return m, ok
+}
+
+// MCCCodes lists the bundled MCC table ordered by code
+func MCCCodes() []MCC {
+	codes := make([]MCC, 0, len(mccTable))
+	for _, m := range mccTable {
+		codes = append(codes, m)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+	return codes
+}
+
+// Group is the card network range the code belongs to
+func (m MCC) Group() string {
+	switch c := m.Code; {
+	case c < "1500":
+		return "agricultural_services"
+	case c < "3000":
+		return "contracted_services"
+	case c < "4000":
+		return "travel_and_entertainment"
+	case c < "4800":
+		return "transportation"
+	case c < "5000":
+		return "utilities"
+	case c < "5600":
+		return "retail_outlets"
+	case c < "5700":
+		return "clothing_stores"
+	case c < "7300":
+		return "miscellaneous_stores"
+	case c < "8000":
+		return "business_services"
+	case c < "9000":
+		return "professional_services"
+	default:
+		return "government_services"
+	}
+}
@@ -0,0 +1,12 @@
+package entity
+
+import "fmt"
+
+// FormatCents renders an amount in cents with two decimals (-150 -> "-1.50")
+func FormatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MerchantOwnership is a period in which a business owned a merchant,
+// ValidTo is nil for the current owner
+type MerchantOwnership struct {
+	MerchantID uuid.UUID
+	BusinessID uuid.UUID
+	ValidFrom  time.Time
+	ValidTo    *time.Time
+}
+
+// MerchantTransfer moves a merchant to another business from EffectiveAt on.
+// Transactions processed since then are attributed to the new owner, the
+// commission they were charged is kept.
+type MerchantTransfer struct {
+	MerchantID     uuid.UUID
+	FromBusinessID uuid.UUID
+	ToBusinessID   uuid.UUID
+	EffectiveAt    time.Time
+	Reattributed   int64 // transactions moved to the new owner
+}
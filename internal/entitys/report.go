@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReportGroup string
+
+const (
+	GroupByMerchant ReportGroup = "merchant"
+	GroupByBusiness ReportGroup = "business"
+	GroupByRoot     ReportGroup = "business_group" // top level businesses, including their descendants
+	GroupByTerminal ReportGroup = "terminal"       // transactions without a terminal are left out
+)
+
+type ReportMetric string
+
+const (
+	MetricVolume ReportMetric = "volume" // Sum of Amount
+	MetricFees   ReportMetric = "fees"   // Sum of Fee
+	MetricCount  ReportMetric = "count"  // Number of transactions
+)
+
+// AggregateRow holds the totals of one merchant or business over a period
+type AggregateRow struct {
+	ID     uuid.UUID
+	Count  int64
+	Volume int64 // cents
+	Fees   int64 // cents
+}
+
+// Value returns the row total for the given metric
+func (r AggregateRow) Value(m ReportMetric) int64 {
+	switch m {
+	case MetricFees:
+		return r.Fees
+	case MetricCount:
+		return r.Count
+	default:
+		return r.Volume
+	}
+}
+
+type LeaderboardEntry struct {
+	Rank          int
+	ID            uuid.UUID
+	Count         int64
+	Volume        int64 // cents
+	Fees          int64 // cents
+	Value         int64 // Selected metric in the period
+	PreviousValue int64 // Selected metric in the previous period
+	PreviousRank  int   // 0 when absent from the previous period
+	Change        int64
+	ChangePct     *float64 // nil when there is no previous value
+}
+
+type Leaderboard struct {
+	Group        ReportGroup
+	Metric       ReportMetric
+	From         time.Time
+	To           time.Time
+	PreviousFrom time.Time
+	PreviousTo   time.Time
+	Entries      []LeaderboardEntry
+}
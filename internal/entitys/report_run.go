@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ScheduledReport string
+
+const (
+	ReportDailyRevenue       ScheduledReport = "daily_revenue"
+	ReportMerchantStatements ScheduledReport = "merchant_statements"
+	ReportReconciliation     ScheduledReport = "reconciliation"
+)
+
+type ReportRunStatus string
+
+const (
+	RunRunning   ReportRunStatus = "running"
+	RunSucceeded ReportRunStatus = "succeeded"
+	RunFailed    ReportRunStatus = "failed"
+)
+
+// ReportTable is a rendered-agnostic report output, one file per table
+type ReportTable struct {
+	Name   string // file name without extension
+	Header []string
+	Rows   [][]string
+}
+
+// ReportFile describes a file written to the outbox (manifest entry)
+type ReportFile struct {
+	Name   string
+	Size   int64
+	Rows   int
+	SHA256 string
+}
+
+// ReportRun is one attempt of a scheduled report
+type ReportRun struct {
+	ID           uuid.UUID
+	Report       ScheduledReport
+	ScheduledFor time.Time
+	PeriodStart  time.Time
+	PeriodEnd    time.Time
+	Attempt      int
+	Status       ReportRunStatus
+	Error        string
+	OutputDir    string
+	Files        int
+	StartedAt    time.Time
+	FinishedAt   *time.Time
+}
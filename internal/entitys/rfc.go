@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"regexp"
+	"time"
+)
+
+// RFC layout: 3 (company) or 4 (individual) letters, YYMMDD, 2 char homoclave
+// and the check digit
+var rfcPattern = regexp.MustCompile(`^([A-ZÑ&]{3,4})([0-9]{6})([A-Z0-9]{2})([0-9A])$`)
+
+// Generic RFCs issued by the SAT, they do not carry a real check digit
+var genericRFCs = map[string]bool{
+	"XAXX010101000": true, // público en general
+	"XEXX010101000": true, // extranjeros
+}
+
+const rfcAlphabet = "0123456789ABCDEFGHIJKLMN&OPQRSTUVWXYZ Ñ"
+
+// ValidateRFC checks the format, the embedded date and the check digit of a
+// Mexican RFC (tax ID)
+func ValidateRFC(rfc string) error {
+	if genericRFCs[rfc] {
+		return nil
+	}
+	m := rfcPattern.FindStringSubmatch(rfc)
+	if m == nil {
+		return &ValidationError{Field: "rfc", Reason: "invalid format"}
+	}
+	if _, err := time.Parse("060102", m[2]); err != nil {
+		return &ValidationError{Field: "rfc", Reason: "invalid date"}
+	}
+	if rfcCheckDigit(rfc) != []rune(rfc)[len([]rune(rfc))-1] {
+		return &ValidationError{Field: "rfc", Reason: "invalid check digit"}
+	}
+	return nil
+}
+
+// rfcCheckDigit computes the modulo 11 digit over the first 12 characters,
+// company RFCs are left padded with a space to 13
+func rfcCheckDigit(rfc string) rune {
+	chars := []rune(rfc)
+	if len(chars) == 12 {
+		chars = append([]rune{' '}, chars...)
+	}
+
+	alphabet := []rune(rfcAlphabet)
+	sum := 0
+	for i, c := range chars[:12] {
+		value := 0
+		for j, a := range alphabet {
+			if a == c {
+				value = j
+				break
+			}
+		}
+		sum += value * (13 - i)
+	}
+
+	switch mod := sum % 11; {
+	case mod == 0:
+		return '0'
+	case 11-mod == 10:
+		return 'A'
+	default:
+		return rune('0' + 11 - mod)
+	}
+}
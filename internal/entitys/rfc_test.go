@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateRFC(t *testing.T) {
+	tests := []struct {
+		rfc    string
+		reason string // empty when valid
+	}{
+		{"EKU9003173C9", ""},
+		{"GODE561231GR8", ""},
+		{"XIA190128J61", ""},
+		{"CACX7605101P8", ""},
+		{"XAXX010101000", ""},
+		{"XEXX010101000", ""},
+
+		{"", "invalid format"},
+		{"eku9003173c9", "invalid format"},
+		{"EKU9003173C", "invalid format"},
+		{"EK9003173C9", "invalid format"},
+		{"EKU900317-3C9", "invalid format"},
+		{"EKU9003173CB", "invalid format"},
+		{"GODE561231GR89", "invalid format"},
+		{"EKU9013173C9", "invalid date"},
+		{"GODE560231GR8", "invalid date"},
+		{"EKU9003173C8", "invalid check digit"},
+		{"GODE561231GR9", "invalid check digit"},
+		{"CACX7605101PA", "invalid check digit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rfc, func(t *testing.T) {
+			err := ValidateRFC(tt.rfc)
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("ValidateRFC(%q) = %v, want valid", tt.rfc, err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("ValidateRFC(%q) = %v, want a ValidationError", tt.rfc, err)
+			}
+			if verr.Field != "rfc" || verr.Reason != tt.reason {
+				t.Errorf("ValidateRFC(%q) = %v, want rfc: %s", tt.rfc, err, tt.reason)
+			}
+		})
+	}
+}
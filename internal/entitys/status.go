@@ -0,0 +1,85 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+type MerchantStatus string
+
+const (
+	MerchantOnboarding MerchantStatus = "onboarding"
+	MerchantActive     MerchantStatus = "active"
+	MerchantSuspended  MerchantStatus = "suspended"
+	MerchantClosed     MerchantStatus = "closed"
+)
+
+// merchantTransitions lists the allowed moves, closed is final
+var merchantTransitions = map[MerchantStatus][]MerchantStatus{
+	MerchantOnboarding: {MerchantActive, MerchantClosed},
+	MerchantActive:     {MerchantSuspended, MerchantClosed},
+	MerchantSuspended:  {MerchantActive, MerchantClosed},
+}
+
+func (s MerchantStatus) Valid() bool {
+	switch s {
+	case MerchantOnboarding, MerchantActive, MerchantSuspended, MerchantClosed:
+		return true
+	}
+	return false
+}
+
+func (s MerchantStatus) CanTransitionTo(to MerchantStatus) bool {
+	for _, next := range merchantTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+type BusinessStatus string
+
+const (
+	BusinessActive    BusinessStatus = "active"
+	BusinessSuspended BusinessStatus = "suspended"
+)
+
+func (s BusinessStatus) Valid() bool {
+	return s == BusinessActive || s == BusinessSuspended
+}
+
+// StatusChange is the last transition applied to a merchant or business
+type StatusChange struct {
+	StatusReason    string
+	StatusChangedAt *time.Time
+}
+
+func invalidTransition(from, to string) error {
+	return &RejectedError{
+		Code:   CodeInvalidStatusTransition,
+		Reason: fmt.Sprintf("cannot move from %s to %s", from, to),
+	}
+}
+
+// CheckTransition validates a merchant status change
+func (s MerchantStatus) CheckTransition(to MerchantStatus) error {
+	if !to.Valid() {
+		return &ValidationError{Field: "status", Reason: "must be onboarding, active, suspended or closed"}
+	}
+	if !s.CanTransitionTo(to) {
+		return invalidTransition(string(s), string(to))
+	}
+	return nil
+}
+
+// CheckTransition validates a business status change
+func (s BusinessStatus) CheckTransition(to BusinessStatus) error {
+	if !to.Valid() {
+		return &ValidationError{Field: "status", Reason: "must be active or suspended"}
+	}
+	if s == to {
+		return invalidTransition(string(s), string(to))
+	}
+	return nil
+}
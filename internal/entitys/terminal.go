@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TerminalStatus string
+
+const (
+	TerminalActive   TerminalStatus = "active"
+	TerminalInactive TerminalStatus = "inactive" // deactivated, final
+)
+
+// Terminal is a POS device of a card-present merchant
+type Terminal struct {
+	ID           uuid.UUID
+	MerchantID   uuid.UUID
+	SerialNumber string // unique across the system
+	Model        string
+	Status       TerminalStatus
+	StatusChange
+	LastSeenAt *time.Time // timestamp of its latest transaction
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Validate normalizes the serial number (trimmed, upper case) and checks
+// the required fields
+func (t *Terminal) Validate() error {
+	t.SerialNumber = strings.ToUpper(strings.TrimSpace(t.SerialNumber))
+	t.Model = strings.TrimSpace(t.Model)
+	if t.SerialNumber == "" {
+		return &ValidationError{Field: "serial_number", Reason: "is required"}
+	}
+	if len(t.SerialNumber) > 64 {
+		return &ValidationError{Field: "serial_number", Reason: "must be at most 64 characters"}
+	}
+	if len(t.Model) > 64 {
+		return &ValidationError{Field: "model", Reason: "must be at most 64 characters"}
+	}
+	return nil
+}
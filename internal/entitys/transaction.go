@@ -7,11 +7,16 @@ import (
 )
 
 type Transaction struct {
-	ID         uuid.UUID
-	MerchantID uuid.UUID
-	Amount     int64 // Value in cents (200.00 -> 20000)
-	Commission int64 // in percebt
-	Fee        int64 // in centi% 5.5=550
-	Timestamp  time.Time
-	DeletedAt  *time.Time
+	ID               uuid.UUID
+	MerchantID       uuid.UUID
+	BusinessID       uuid.UUID        // owner of the merchant at processing time
+	TerminalID       *uuid.UUID       // POS device, nil for card-not-present
+	MCC              string           // merchant category code at processing time
+	Amount           int64            // Value in cents (200.00 -> 20000)
+	Commission       int64            // in percebt
+	CommissionSource CommissionSource // business rate or merchant override
+	Fee              int64            // in centi% 5.5=550
+	Timestamp        time.Time
+	RefundedAt       *time.Time // set once the full amount is refunded
+	DeletedAt        *time.Time
 }
@@ -2,66 +2,249 @@ package usecase
 
 import (
 	"context"
+	"io"
+	"time"
 
 	entity "github.com/CardenalDex/crudprotec/internal/entitys"
 	"github.com/google/uuid"
 )
 
 // ///////////////////db
+
+// UnitOfWork runs fn atomically: every repository call made with the ctx
+// handed to fn commits or rolls back together, so an entity write and its
+// audit entry never go one without the other
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type BusinessRepository interface {
 	CreateBusiness(ctx context.Context, b *entity.Business) error
 	GetBusinessByID(ctx context.Context, id uuid.UUID) (*entity.Business, error)
+	GetBusinessIncludingDeleted(ctx context.Context, id uuid.UUID) (*entity.Business, error)
+	ListBusinesses(ctx context.Context, filter entity.BusinessFilter) ([]entity.BusinessListItem, int64, error)
 	UpdateBusiness(ctx context.Context, b *entity.Business) error
-	DeleteBusiness(ctx context.Context, id uuid.UUID) error
+	DeleteBusiness(ctx context.Context, d *entity.BusinessDeletion) error
+	RestoreBusiness(ctx context.Context, id uuid.UUID) error
+	ListBusinessTree(ctx context.Context, rootID uuid.UUID) ([]entity.BusinessNode, error)
 }
 
 type MerchantRepository interface {
 	CreateMerchant(ctx context.Context, m *entity.Merchant) error
 	GetMerchantByID(ctx context.Context, id uuid.UUID) (*entity.Merchant, error)
-	GetMerchantByBusinessID(ctx context.Context, businessID uuid.UUID) ([]entity.Merchant, error)
+	GetMerchantIncludingDeleted(ctx context.Context, id uuid.UUID) (*entity.Merchant, error)
+	GetMerchantByBusinessID(ctx context.Context, businessID uuid.UUID, includeDeleted bool) ([]entity.Merchant, error)
+	UpdateMerchant(ctx context.Context, m *entity.Merchant) error
 	DeleteMerchant(ctx context.Context, id uuid.UUID) error
+	RestoreMerchant(ctx context.Context, id uuid.UUID) error
+	TransferMerchant(ctx context.Context, t *entity.MerchantTransfer) error
+	ListMerchantOwnership(ctx context.Context, merchantID uuid.UUID) ([]entity.MerchantOwnership, error)
+}
+
+type TerminalRepository interface {
+	CreateTerminal(ctx context.Context, t *entity.Terminal) error
+	GetTerminalByID(ctx context.Context, id uuid.UUID) (*entity.Terminal, error)
+	ListMerchantTerminals(ctx context.Context, merchantID uuid.UUID) ([]entity.Terminal, error)
+	UpdateTerminal(ctx context.Context, t *entity.Terminal) error
 }
 
 type TransactionRepository interface {
 	CreateTransaction(ctx context.Context, t *entity.Transaction) error
 	GetTransactionByID(ctx context.Context, id uuid.UUID) (*entity.Transaction, error)
+	RefundTransaction(ctx context.Context, id uuid.UUID, at time.Time) error
 	TransactionListByMerchant(ctx context.Context, merchantID uuid.UUID) ([]entity.Transaction, error)
+	TransactionListByMerchantBetween(ctx context.Context, merchantID uuid.UUID, from, to time.Time) ([]entity.Transaction, error)
+	TransactionListByBusinessBetween(ctx context.Context, businessID uuid.UUID, from, to time.Time) ([]entity.Transaction, error)
+	TransactionListByTerminal(ctx context.Context, terminalID uuid.UUID) ([]entity.Transaction, error)
+	GetTransactionsBetween(ctx context.Context, from, to time.Time) ([]entity.Transaction, error)
 	GetAllTransaction(ctx context.Context) ([]entity.Transaction, error)
 }
 
+// SummaryRepository exposes the per-merchant daily rollups maintained by
+// TransactionRepository on every write
+type SummaryRepository interface {
+	ListDailySummaries(ctx context.Context, merchantID uuid.UUID, from, to time.Time) ([]entity.DailySummary, error)
+	ListDailySummariesForDay(ctx context.Context, day time.Time) ([]entity.DailySummary, error)
+	SumDailySummaries(ctx context.Context, merchantID *uuid.UUID) (entity.DailySummary, error)
+	RebuildDailySummaries(ctx context.Context, from, to time.Time) (int, error)
+}
+
+type ReportRepository interface {
+	AggregateTransactions(ctx context.Context, group entity.ReportGroup, from, to time.Time) ([]entity.AggregateRow, error)
+}
+
+type ReportRunRepository interface {
+	CreateReportRun(ctx context.Context, r *entity.ReportRun) error
+	UpdateReportRun(ctx context.Context, r *entity.ReportRun) error
+	ListReportRuns(ctx context.Context, report entity.ScheduledReport, status entity.ReportRunStatus, limit int) ([]entity.ReportRun, error)
+}
+
+// OutboxWriter persists the rendered report files of a run, returns where
+type OutboxWriter interface {
+	Write(ctx context.Context, run *entity.ReportRun, tables []entity.ReportTable) (string, []entity.ReportFile, error)
+}
+
 type LogRepository interface {
 	CreateLog(ctx context.Context, l *entity.Log) error
 	GetLogByID(ctx context.Context, logID string) (entity.Log, error)
 	GetLogByResource(ctx context.Context, resourceID string) ([]entity.Log, error)
-	GetAll(ctx context.Context) ([]entity.Log, error)
+	SearchLogs(ctx context.Context, f entity.LogFilter) ([]entity.Log, error)
+}
+
+// AuditChainRepository reads the hash chain kept by LogRepository.CreateLog
+type AuditChainRepository interface {
+	GetLogChainHead(ctx context.Context) (*entity.Log, error)
+	ListLogChain(ctx context.Context, afterSeq int64, limit int) ([]entity.Log, error)
+	CreateAuditAnchor(ctx context.Context, a *entity.AuditAnchor) error
+	ListAuditAnchors(ctx context.Context) ([]entity.AuditAnchor, error)
+}
+
+// AuditArchiveRepository moves old entries out of the audit table, the
+// entries themselves go to an AuditArchiveStore
+type AuditArchiveRepository interface {
+	ListArchivableLogs(ctx context.Context, cutoff time.Time, limit int) ([]entity.Log, error)
+	SaveAuditArchive(ctx context.Context, a *entity.AuditArchive) error
+	ListAuditArchives(ctx context.Context) ([]entity.AuditArchive, error)
+}
+
+// AuditArchiveStore keeps the archived entries, Write returns the file name
+// and its checksum, Read fails when the checksum does not match
+type AuditArchiveStore interface {
+	Write(ctx context.Context, entries []entity.Log) (string, string, error)
+	Read(ctx context.Context, a entity.AuditArchive) ([]entity.Log, error)
+}
+
+// AuditFeed signals new audit entries once they are committed, the entries
+// themselves are read back with AuditChainRepository.ListLogChain. Signals
+// are coalesced, a subscriber that falls behind never blocks the writers.
+type AuditFeed interface {
+	SubscribeLogs() (<-chan struct{}, func())
+}
+
+// AuditBundleWriter signs an export: it completes the manifest with the
+// entries checksum and its public key, then writes the bundle to w
+type AuditBundleWriter interface {
+	WriteBundle(w io.Writer, m *entity.BundleManifest, entries []entity.Log) error
+}
+
+// AccessLogRepository stores the read-access records, outside the audit chain
+type AccessLogRepository interface {
+	CreateAccessLog(ctx context.Context, a *entity.AccessLog) error
+	ListAccessLogs(ctx context.Context, f entity.AccessLogFilter) ([]entity.AccessLog, error)
+	DeleteAccessLogsBefore(ctx context.Context, cutoff time.Time) (int64, error)
 }
 
 // /////////////////////////////////////////////////////gin tonic
 type TransactionUseCase interface {
-	ProcessTransaction(ctx context.Context, actor string, merchantID uuid.UUID, amount int64) (*entity.Transaction, error)
+	ProcessTransaction(ctx context.Context, actor string, merchantID uuid.UUID, amount int64, terminalID *uuid.UUID) (*entity.Transaction, error)
 	GetTransaction(ctx context.Context, id uuid.UUID) (*entity.Transaction, error)
+	RefundTransaction(ctx context.Context, actor string, id uuid.UUID) (*entity.Transaction, error)
 	GetMerchantTransactions(ctx context.Context, merchantID uuid.UUID) ([]entity.Transaction, error)
 	GetAllTransactions(ctx context.Context) ([]entity.Transaction, error)
 	//revenue
 	GetAllRevenue(ctx context.Context) (float64, error)
 	GetAllRevenueByMerchant(ctx context.Context, merchantID uuid.UUID) (float64, error)
+	GetMerchantDailySummaries(ctx context.Context, merchantID uuid.UUID, from, to time.Time) ([]entity.DailySummary, error)
 }
 
 type MerchantUseCase interface {
-	RegisterMerchant(ctx context.Context, actor string, businessID uuid.UUID) (*entity.Merchant, error)
-	GetMerchant(ctx context.Context, id uuid.UUID) (*entity.Merchant, error)
-	GetBusinessMerchants(ctx context.Context, businessID uuid.UUID) ([]entity.Merchant, error)
+	RegisterMerchant(ctx context.Context, actor string, businessID uuid.UUID, profile entity.MerchantProfile, status entity.MerchantStatus) (*entity.Merchant, error)
+	GetMerchant(ctx context.Context, id uuid.UUID, includeDeleted bool) (*entity.Merchant, error)
+	GetBusinessMerchants(ctx context.Context, businessID uuid.UUID, includeDeleted bool) ([]entity.Merchant, error)
+	UpdateMerchantProfile(ctx context.Context, actor string, id uuid.UUID, patch entity.MerchantProfilePatch) (*entity.Merchant, error)
+	ChangeMerchantStatus(ctx context.Context, actor string, id uuid.UUID, status entity.MerchantStatus, reason string) (*entity.Merchant, error)
 	RemoveMerchant(ctx context.Context, actor string, id uuid.UUID) error
+	RestoreMerchant(ctx context.Context, actor string, id uuid.UUID, reason string) (*entity.Merchant, error)
+	TransferMerchant(ctx context.Context, actor string, id, toBusinessID uuid.UUID, effectiveAt time.Time) (*entity.MerchantTransfer, error)
+	SetCommissionOverride(ctx context.Context, actor string, id uuid.UUID, override *entity.CommissionOverride) (*entity.Merchant, error)
+	GetMerchantOwnership(ctx context.Context, id uuid.UUID) ([]entity.MerchantOwnership, error)
+}
+
+type TerminalUseCase interface {
+	RegisterTerminal(ctx context.Context, actor string, merchantID uuid.UUID, serialNumber, model string) (*entity.Terminal, error)
+	GetTerminal(ctx context.Context, id uuid.UUID) (*entity.Terminal, error)
+	GetMerchantTerminals(ctx context.Context, merchantID uuid.UUID) ([]entity.Terminal, error)
+	DeactivateTerminal(ctx context.Context, actor string, id uuid.UUID, reason string) (*entity.Terminal, error)
+	GetTerminalTransactions(ctx context.Context, id uuid.UUID) ([]entity.Transaction, error)
 }
 
 type AdminUseCase interface {
-	RegisterBusiness(ctx context.Context, actor string, commission int64) (*entity.Business, error)
-	GetBusiness(ctx context.Context, id uuid.UUID) (*entity.Business, error)
+	RegisterBusiness(ctx context.Context, actor string, commission int64, profile entity.BusinessProfile, parentID *uuid.UUID) (*entity.Business, error)
+	GetBusiness(ctx context.Context, id uuid.UUID, includeDeleted bool) (*entity.Business, error)
+	ListBusinesses(ctx context.Context, filter entity.BusinessFilter) (*entity.BusinessPage, error)
 	UpdateBusinessCommission(ctx context.Context, actor string, id uuid.UUID, newCommission int64) (*entity.Business, error)
-	RemoveBusiness(ctx context.Context, actor string, id uuid.UUID) error
+	UpdateBusinessFiscalData(ctx context.Context, actor string, id uuid.UUID, fiscal entity.FiscalData) (*entity.Business, error)
+	UpdateBusinessProfile(ctx context.Context, actor string, id uuid.UUID, patch entity.BusinessProfilePatch) (*entity.Business, error)
+	ChangeBusinessStatus(ctx context.Context, actor string, id uuid.UUID, status entity.BusinessStatus, reason string) (*entity.Business, error)
+	SetBusinessParent(ctx context.Context, actor string, id uuid.UUID, parentID *uuid.UUID) (*entity.Business, error)
+	GetBusinessDescendants(ctx context.Context, id uuid.UUID) ([]entity.BusinessNode, error)
+	RemoveBusiness(ctx context.Context, actor string, id uuid.UUID, policy entity.DeletionPolicy, target *uuid.UUID) (*entity.BusinessDeletion, error)
+	RestoreBusiness(ctx context.Context, actor string, id uuid.UUID, reason string) (*entity.Business, error)
+}
 
-	// Auditing
+type AuditUseCase interface {
+	// VerifyChain walks the whole chain, checking it against the stored
+	// anchors and the extra ones given (e.g. exported earlier)
+	VerifyChain(ctx context.Context, extra []entity.AuditAnchor) (*entity.ChainVerification, error)
+	CreateAnchor(ctx context.Context) (*entity.AuditAnchor, error)
+	ListAnchors(ctx context.Context) ([]entity.AuditAnchor, error)
+
+	// GetAuditTrail and GetLogDetails read the archives too
 	GetAuditTrail(ctx context.Context, resourceID string) ([]entity.Log, error)
 	GetLogDetails(ctx context.Context, logID string) (entity.Log, error)
-	GetAllLogs(ctx context.Context) ([]entity.Log, error)
+
+	// SearchLogs reads the archives too when filter.Archived is set or
+	// filter.From reaches before the oldest entry still in the database
+	SearchLogs(ctx context.Context, filter entity.LogFilter) (*entity.LogPage, error)
+	// ArchiveLogs moves the entries older than cutoff to the archive store
+	ArchiveLogs(ctx context.Context, cutoff time.Time) ([]entity.AuditArchive, error)
+	ListArchives(ctx context.Context) ([]entity.AuditArchive, error)
+	// ExportBundle writes a signed bundle of the entries matching the range
+	// or resource of filter, archived ones included, oldest first
+	ExportBundle(ctx context.Context, actor string, filter entity.LogFilter, w io.Writer) (*entity.BundleManifest, error)
+	// GetBusinessAsOf and GetMerchantAsOf rebuild a resource as it was at a
+	// point in time from the audit snapshots
+	GetBusinessAsOf(ctx context.Context, id uuid.UUID, at time.Time) (*entity.HistoricalBusiness, error)
+	GetMerchantAsOf(ctx context.Context, id uuid.UUID, at time.Time) (*entity.HistoricalMerchant, error)
+	// StreamLogs hands send the entries matching filter written after
+	// afterSeq (-1 starts at the current head), then each new one once
+	// committed, until ctx ends or send fails. send gets nil once the stream
+	// is open and every heartbeat while idle, so a gone client is noticed.
+	StreamLogs(ctx context.Context, filter entity.LogFilter, afterSeq int64, send func(l *entity.Log) error) error
+}
+
+type AccessLogUseCase interface {
+	RecordAccess(ctx context.Context, a *entity.AccessLog) error
+	ListAccessLogs(ctx context.Context, filter entity.AccessLogFilter) ([]entity.AccessLog, error)
+	// PurgeAccessLogs drops the records older than cutoff, returns how many
+	PurgeAccessLogs(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type ReportUseCase interface {
+	Leaderboard(ctx context.Context, group entity.ReportGroup, metric entity.ReportMetric, from, to time.Time, limit int, byDecline bool) (*entity.Leaderboard, error)
+	BusinessRollup(ctx context.Context, businessID uuid.UUID, from, to time.Time) (*entity.BusinessRollup, error)
+}
+
+type ScheduledReportUseCase interface {
+	// RunReport generates the report covering the UTC day before scheduledFor
+	RunReport(ctx context.Context, report entity.ScheduledReport, scheduledFor time.Time, attempt int) (*entity.ReportRun, error)
+	ListReportRuns(ctx context.Context, report entity.ScheduledReport, status entity.ReportRunStatus, limit int) ([]entity.ReportRun, error)
+}
+
+type SimulatorUseCase interface {
+	SimulateCommission(ctx context.Context, businessID uuid.UUID, proposed entity.FeeSchedule, from, to time.Time) (*entity.CommissionSimulation, error)
+}
+
+type InvoiceUseCase interface {
+	GenerateCommissionInvoice(ctx context.Context, actor string, businessID uuid.UUID, from, to time.Time) (*entity.Invoice, error)
+}
+
+// InvoiceRenderer turns an invoice into its CFDI XML document
+type InvoiceRenderer interface {
+	Render(inv *entity.Invoice) ([]byte, error)
+}
+
+// InvoiceStamper seals a rendered CFDI with a PAC; returns the stamped XML
+type InvoiceStamper interface {
+	Stamp(ctx context.Context, xml []byte) ([]byte, *entity.InvoiceStamp, error)
 }
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/google/uuid"
+)
+
+const maxAccessLogPageSize = 500
+
+type accessLogService struct {
+	repo AccessLogRepository
+}
+
+func NewAccessLogService(r AccessLogRepository) AccessLogUseCase {
+	return &accessLogService{repo: r}
+}
+
+func (s *accessLogService) RecordAccess(ctx context.Context, a *entity.AccessLog) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	if a.Timestamp.IsZero() {
+		a.Timestamp = time.Now()
+	}
+	return s.repo.CreateAccessLog(ctx, a)
+}
+
+func (s *accessLogService) ListAccessLogs(ctx context.Context, filter entity.AccessLogFilter) ([]entity.AccessLog, error) {
+	if filter.Limit < 1 {
+		filter.Limit = 100
+	}
+	if filter.Limit > maxAccessLogPageSize {
+		filter.Limit = maxAccessLogPageSize
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, &entity.ValidationError{Field: "from", Reason: "must be before to"}
+	}
+	return s.repo.ListAccessLogs(ctx, filter)
+}
+
+func (s *accessLogService) PurgeAccessLogs(ctx context.Context, cutoff time.Time) (int64, error) {
+	return s.repo.DeleteAccessLogsBefore(ctx, cutoff)
+}
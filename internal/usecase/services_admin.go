@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,93 +12,446 @@ import (
 )
 
 type adminService struct {
-	bizRepo BusinessRepository
-	logRepo LogRepository
+	bizRepo      BusinessRepository
+	merchantRepo MerchantRepository
+	logRepo      LogRepository
+	uow          UnitOfWork
 }
 
-func NewAdminService(br BusinessRepository, lr LogRepository) AdminUseCase {
-	return &adminService{br, lr}
+func NewAdminService(br BusinessRepository, mr MerchantRepository, lr LogRepository, uow UnitOfWork) AdminUseCase {
+	return &adminService{br, mr, lr, uow}
 }
 
-func (s *adminService) RegisterBusiness(ctx context.Context, actor string, commission int64) (*entity.Business, error) {
+// RegisterBusiness creates a business, optionally under parentID. A child
+// created without commission (0) inherits its parent's.
+func (s *adminService) RegisterBusiness(ctx context.Context, actor string, commission int64, profile entity.BusinessProfile, parentID *uuid.UUID) (*entity.Business, error) {
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	if parentID != nil {
+		parent, err := s.bizRepo.GetBusinessByID(ctx, *parentID)
+		if err != nil {
+			return nil, &entity.ValidationError{Field: "parent_id", Reason: "business does not exist"}
+		}
+		if commission == 0 {
+			commission = parent.Commission
+		}
+	}
+	if commission <= 0 {
+		return nil, &entity.ValidationError{Field: "commission_percentage", Reason: "is required and must be greater than 0"}
+	}
+
 	biz := &entity.Business{
-		ID:         uuid.New(),
-		Commission: commission,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+		ID:              uuid.New(),
+		ParentID:        parentID,
+		Commission:      commission,
+		BusinessProfile: profile,
+		Status:          entity.BusinessActive,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
-	if err := s.bizRepo.CreateBusiness(ctx, biz); err != nil {
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.bizRepo.CreateBusiness(ctx, biz); err != nil {
+			return err
+		}
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:             uuid.New(),
+			Action:         entity.ActionBusinessRegistered,
+			Actor:          actor,
+			ResourceID:     biz.ID.String(),
+			PrevResourceID: "",
+			After:          entity.Snapshot(biz),
+			Timestamp:      time.Now(),
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
-	s.logRepo.CreateLog(ctx, &entity.Log{
-		ID:             uuid.New(),
-		Action:         "CREATE_BUSINESS",
-		Actor:          actor,
-		ResourceID:     biz.ID.String(),
-		PrevResourceID: "",
-		Timestamp:      time.Now(),
-	})
 
 	return biz, nil
 }
 
-func (s *adminService) GetAuditTrail(ctx context.Context, resID string) ([]entity.Log, error) {
-	return s.logRepo.GetLogByResource(ctx, resID)
+func (s *adminService) GetBusiness(ctx context.Context, id uuid.UUID, includeDeleted bool) (*entity.Business, error) {
+	if includeDeleted {
+		return s.bizRepo.GetBusinessIncludingDeleted(ctx, id)
+	}
+	return s.bizRepo.GetBusinessByID(ctx, id)
 }
 
-func (s *adminService) GetBusiness(ctx context.Context, id uuid.UUID) (*entity.Business, error) {
-	return s.bizRepo.GetBusinessByID(ctx, id)
+const maxBusinessPageSize = 100
+
+func (s *adminService) ListBusinesses(ctx context.Context, filter entity.BusinessFilter) (*entity.BusinessPage, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 || filter.PageSize > maxBusinessPageSize {
+		filter.PageSize = 20
+	}
+	if filter.MinCommission != nil && filter.MaxCommission != nil && *filter.MinCommission > *filter.MaxCommission {
+		return nil, errors.New("min commission is greater than max commission")
+	}
+
+	items, total, err := s.bizRepo.ListBusinesses(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.BusinessPage{
+		Items:    items,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+		Total:    total,
+	}, nil
 }
 
 func (s *adminService) UpdateBusinessCommission(ctx context.Context, actor string, id uuid.UUID, newCommission int64) (*entity.Business, error) {
+	var biz *entity.Business
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if biz, err = s.bizRepo.GetBusinessByID(ctx, id); err != nil {
+			return err
+		}
 
-	biz, err := s.bizRepo.GetBusinessByID(ctx, id)
+		before := entity.Snapshot(biz)
+		oldCommission := biz.Commission
+		biz.Commission = newCommission
+		biz.UpdatedAt = time.Now()
+		if err := s.bizRepo.UpdateBusiness(ctx, biz); err != nil {
+			return err
+		}
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:             uuid.New(),
+			Action:         entity.ActionBusinessCommissionUpdated,
+			Actor:          actor,
+			ResourceID:     id.String(),
+			PrevResourceID: fmt.Sprintf("old_comm:%d", oldCommission),
+			Before:         before,
+			After:          entity.Snapshot(biz),
+			Timestamp:      time.Now(),
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	oldCommission := biz.Commission
-	biz.Commission = newCommission
-	biz.UpdatedAt = time.Now()
+	return biz, nil
+}
+
+func (s *adminService) UpdateBusinessFiscalData(ctx context.Context, actor string, id uuid.UUID, fiscal entity.FiscalData) (*entity.Business, error) {
+	return s.UpdateBusinessProfile(ctx, actor, id, entity.BusinessProfilePatch{
+		LegalName:  &fiscal.LegalName,
+		RFC:        &fiscal.RFC,
+		TaxRegime:  &fiscal.TaxRegime,
+		PostalCode: &fiscal.PostalCode,
+	})
+}
+
+// UpdateBusinessProfile applies a partial update, nothing is saved or audited
+// when no field actually changes
+func (s *adminService) UpdateBusinessProfile(ctx context.Context, actor string, id uuid.UUID, patch entity.BusinessProfilePatch) (*entity.Business, error) {
+	var biz *entity.Business
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if biz, err = s.bizRepo.GetBusinessByID(ctx, id); err != nil {
+			return err
+		}
 
-	if err := s.bizRepo.UpdateBusiness(ctx, biz); err != nil {
+		before := entity.Snapshot(biz)
+		p := &biz.BusinessProfile
+		changed := false
+		setField := func(dst *string, v *string) {
+			if v != nil && *v != *dst {
+				*dst, changed = *v, true
+			}
+		}
+		setField(&p.LegalName, patch.LegalName)
+		setField(&p.TradeName, patch.TradeName)
+		setField(&p.RFC, patch.RFC)
+		setField(&p.TaxRegime, patch.TaxRegime)
+		setField(&p.PostalCode, patch.PostalCode)
+		setField(&p.ContactEmail, patch.ContactEmail)
+		setField(&p.Phone, patch.Phone)
+		if patch.Address != nil && *patch.Address != p.Address {
+			p.Address, changed = *patch.Address, true
+		}
+
+		if !changed {
+			return nil
+		}
+		if err := p.Validate(); err != nil {
+			return err
+		}
+
+		biz.UpdatedAt = time.Now()
+		if err := s.bizRepo.UpdateBusiness(ctx, biz); err != nil {
+			return err
+		}
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:         uuid.New(),
+			Action:     entity.ActionBusinessProfileUpdated,
+			Actor:      actor,
+			ResourceID: id.String(),
+			Before:     before,
+			After:      entity.Snapshot(biz),
+			Timestamp:  biz.UpdatedAt,
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	s.logRepo.CreateLog(ctx, &entity.Log{
-		ID:             uuid.New(),
-		Action:         "UPDATE_BUSINESS_COMMISSION",
-		Actor:          actor,
-		ResourceID:     id.String(),
-		PrevResourceID: fmt.Sprintf("old_comm:%d", oldCommission),
-		Timestamp:      time.Now(),
+	return biz, nil
+}
+
+// ChangeBusinessStatus suspends or reactivates a business, while suspended
+// none of its merchants can process transactions
+func (s *adminService) ChangeBusinessStatus(ctx context.Context, actor string, id uuid.UUID, status entity.BusinessStatus, reason string) (*entity.Business, error) {
+	if reason == "" {
+		return nil, &entity.ValidationError{Field: "reason", Reason: "is required"}
+	}
+
+	var biz *entity.Business
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if biz, err = s.bizRepo.GetBusinessByID(ctx, id); err != nil {
+			return err
+		}
+		if err := biz.Status.CheckTransition(status); err != nil {
+			return err
+		}
+
+		before := entity.Snapshot(biz)
+		old := biz.Status
+		now := time.Now()
+		biz.Status = status
+		biz.StatusReason = reason
+		biz.StatusChangedAt = &now
+		biz.UpdatedAt = now
+		if err := s.bizRepo.UpdateBusiness(ctx, biz); err != nil {
+			return err
+		}
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:             uuid.New(),
+			Action:         entity.ActionBusinessStatusChanged,
+			Actor:          actor,
+			ResourceID:     id.String(),
+			PrevResourceID: fmt.Sprintf("status:%s", old),
+			Before:         before,
+			After:          entity.Snapshot(biz),
+			Timestamp:      now,
+		})
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return biz, nil
 }
 
-func (s *adminService) RemoveBusiness(ctx context.Context, actor string, id uuid.UUID) error {
+// SetBusinessParent moves the business (with its whole subtree) under
+// parentID, nil makes it a top level business
+func (s *adminService) SetBusinessParent(ctx context.Context, actor string, id uuid.UUID, parentID *uuid.UUID) (*entity.Business, error) {
+	var biz *entity.Business
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if biz, err = s.bizRepo.GetBusinessByID(ctx, id); err != nil {
+			return err
+		}
 
-	if err := s.bizRepo.DeleteBusiness(ctx, id); err != nil {
-		return err
-	}
+		if parentID != nil {
+			if _, err := s.bizRepo.GetBusinessByID(ctx, *parentID); err != nil {
+				return &entity.ValidationError{Field: "parent_id", Reason: "business does not exist"}
+			}
+			// the new parent cannot be the business itself nor one of its
+			// descendants: walk up from it, in the same transaction as the
+			// update so a concurrent move cannot close a loop meanwhile
+			seen := map[uuid.UUID]bool{}
+			for cur := parentID; cur != nil && !seen[*cur]; {
+				if *cur == id {
+					return &entity.RejectedError{Code: entity.CodeHierarchyCycle, Reason: "parent " + parentID.String() + " is inside the subtree of the business"}
+				}
+				seen[*cur] = true
+				ancestor, err := s.bizRepo.GetBusinessIncludingDeleted(ctx, *cur)
+				if err != nil {
+					return err
+				}
+				cur = ancestor.ParentID
+			}
+		}
 
-	_ = s.logRepo.CreateLog(ctx, &entity.Log{
-		ID:         uuid.New(),
-		Action:     "DELETE_BUSINESS",
-		Actor:      actor,
-		ResourceID: id.String(),
-		Timestamp:  time.Now(),
+		old := "none"
+		if biz.ParentID != nil {
+			if parentID != nil && *biz.ParentID == *parentID {
+				return nil
+			}
+			old = biz.ParentID.String()
+		} else if parentID == nil {
+			return nil
+		}
+
+		before := entity.Snapshot(biz)
+		biz.ParentID = parentID
+		biz.UpdatedAt = time.Now()
+		if err := s.bizRepo.UpdateBusiness(ctx, biz); err != nil {
+			return err
+		}
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:             uuid.New(),
+			Action:         entity.ActionBusinessParentUpdated,
+			Actor:          actor,
+			ResourceID:     id.String(),
+			PrevResourceID: fmt.Sprintf("parent:%s", old),
+			Before:         before,
+			After:          entity.Snapshot(biz),
+			Timestamp:      biz.UpdatedAt,
+		})
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	return biz, nil
 }
 
-func (s *adminService) GetLogDetails(ctx context.Context, logID string) (entity.Log, error) {
-	return s.logRepo.GetLogByID(ctx, logID)
+// GetBusinessDescendants lists every live business below id, closest first
+func (s *adminService) GetBusinessDescendants(ctx context.Context, id uuid.UUID) ([]entity.BusinessNode, error) {
+	nodes, err := s.bizRepo.ListBusinessTree(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, &entity.NotFoundError{Resource: "business"}
+	}
+	return nodes[1:], nil
 }
 
-func (s *adminService) GetAllLogs(ctx context.Context) ([]entity.Log, error) {
-	return s.logRepo.GetAll(ctx)
+// RemoveBusiness deletes the business following policy (block by default),
+// every merchant deleted or reassigned along with it gets its own audit entry
+func (s *adminService) RemoveBusiness(ctx context.Context, actor string, id uuid.UUID, policy entity.DeletionPolicy, target *uuid.UUID) (*entity.BusinessDeletion, error) {
+	if policy == "" {
+		policy = entity.DeleteBlock
+	}
+	if !policy.Valid() {
+		return nil, &entity.ValidationError{Field: "policy", Reason: "must be block, cascade or reassign"}
+	}
+	if policy != entity.DeleteReassign {
+		target = nil
+	} else if target != nil && *target == id {
+		return nil, &entity.ValidationError{Field: "target_business_id", Reason: "cannot reassign merchants to the deleted business"}
+	}
+
+	d := &entity.BusinessDeletion{BusinessID: id, Policy: policy, TargetBusinessID: target}
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		biz, err := s.bizRepo.GetBusinessByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		merchants, err := s.merchantRepo.GetMerchantByBusinessID(ctx, id, false)
+		if err != nil {
+			return err
+		}
+		merchantsBefore := make(map[uuid.UUID]json.RawMessage, len(merchants))
+		for i := range merchants {
+			merchantsBefore[merchants[i].ID] = entity.Snapshot(&merchants[i])
+		}
+
+		if err := s.bizRepo.DeleteBusiness(ctx, d); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		after, err := s.bizRepo.GetBusinessIncludingDeleted(ctx, id)
+		if err != nil {
+			return err
+		}
+		err = s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:             uuid.New(),
+			Action:         entity.ActionBusinessDeleted,
+			Actor:          actor,
+			ResourceID:     id.String(),
+			PrevResourceID: fmt.Sprintf("policy:%s", policy),
+			Before:         entity.Snapshot(biz),
+			After:          entity.Snapshot(after),
+			Timestamp:      now,
+		})
+		if err != nil {
+			return err
+		}
+		for _, mID := range d.Merchants {
+			m, err := s.merchantRepo.GetMerchantIncludingDeleted(ctx, mID)
+			if err != nil {
+				return err
+			}
+			action := entity.ActionMerchantDeleted
+			if policy == entity.DeleteReassign {
+				action = entity.ActionMerchantReassigned
+			}
+			err = s.logRepo.CreateLog(ctx, &entity.Log{
+				ID:             uuid.New(),
+				Action:         action,
+				Actor:          actor,
+				ResourceID:     mID.String(),
+				PrevResourceID: fmt.Sprintf("business:%s", id),
+				Before:         merchantsBefore[mID],
+				After:          entity.Snapshot(m),
+				Timestamp:      now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// RestoreBusiness undoes a soft delete. Merchants removed by a cascade stay
+// deleted, they are restored one by one.
+func (s *adminService) RestoreBusiness(ctx context.Context, actor string, id uuid.UUID, reason string) (*entity.Business, error) {
+	if reason == "" {
+		return nil, &entity.ValidationError{Field: "reason", Reason: "is required"}
+	}
+
+	var restored *entity.Business
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		biz, err := s.bizRepo.GetBusinessIncludingDeleted(ctx, id)
+		if err != nil {
+			return err
+		}
+		if biz.DeletedAt == nil {
+			return &entity.RejectedError{Code: entity.CodeNotDeleted, Reason: "business is not deleted"}
+		}
+		if biz.ParentID != nil {
+			if _, err := s.bizRepo.GetBusinessByID(ctx, *biz.ParentID); err != nil {
+				return &entity.RejectedError{Code: entity.CodeParentDeleted, Reason: "parent business " + biz.ParentID.String() + " is deleted, restore it first"}
+			}
+		}
+
+		if err := s.bizRepo.RestoreBusiness(ctx, id); err != nil {
+			return err
+		}
+		if restored, err = s.bizRepo.GetBusinessByID(ctx, id); err != nil {
+			return err
+		}
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:             uuid.New(),
+			Action:         entity.ActionBusinessRestored,
+			Actor:          actor,
+			ResourceID:     id.String(),
+			PrevResourceID: fmt.Sprintf("reason:%s", reason),
+			Before:         entity.Snapshot(biz),
+			After:          entity.Snapshot(restored),
+			Timestamp:      time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
 }
@@ -0,0 +1,557 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/google/uuid"
+)
+
+const (
+	chainBatchSize   = 1000
+	archiveBatchSize = 10000 // entries per archive file
+	maxLogPageSize   = 200
+	maxBundleEntries = 100000
+	streamHeartbeat  = 15 * time.Second
+)
+
+type auditService struct {
+	logRepo     LogRepository
+	chainRepo   AuditChainRepository
+	archiveRepo AuditArchiveRepository
+	store       AuditArchiveStore
+	bundles     AuditBundleWriter // nil when no signing key is configured
+	feed        AuditFeed
+}
+
+func NewAuditService(lr LogRepository, cr AuditChainRepository, ar AuditArchiveRepository, store AuditArchiveStore, bw AuditBundleWriter, f AuditFeed) AuditUseCase {
+	return &auditService{logRepo: lr, chainRepo: cr, archiveRepo: ar, store: store, bundles: bw, feed: f}
+}
+
+// VerifyChain recomputes every hash in sequence order, the archives first,
+// and stops at the first broken link: a missing sequence (deleted entry), a
+// PrevHash not matching the previous entry, content not matching its own
+// hash (edited entry), a normalized action not matching its legacy one or an
+// archive not matching its checksum. Anchors also
+// catch a rewritten chain or a truncated tail.
+func (s *auditService) VerifyChain(ctx context.Context, extra []entity.AuditAnchor) (*entity.ChainVerification, error) {
+	stored, err := s.chainRepo.ListAuditAnchors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	anchors := map[int64][]string{}
+	for _, a := range append(stored, extra...) {
+		anchors[a.Sequence] = append(anchors[a.Sequence], a.Hash)
+	}
+
+	v := &entity.ChainVerification{}
+	fail := func(seq int64, reason string) (*entity.ChainVerification, error) {
+		v.Broken = &entity.ChainBreak{Sequence: seq, Reason: reason}
+		return v, nil
+	}
+	// check walks one entry, nil when it links correctly
+	check := func(l *entity.Log) *entity.ChainBreak {
+		want := v.LastSequence + 1
+		if l.Sequence != want {
+			return &entity.ChainBreak{Sequence: want, Reason: fmt.Sprintf("entry %d is missing, next one is %d", want, l.Sequence)}
+		}
+		broken := func(reason string) *entity.ChainBreak {
+			id := l.ID
+			return &entity.ChainBreak{Sequence: l.Sequence, LogID: &id, Reason: reason}
+		}
+		if l.PrevHash != v.LastHash {
+			return broken("PrevHash does not match the hash of the previous entry")
+		}
+		if l.ComputeHash() != l.Hash {
+			return broken("content does not match its hash, the entry was modified")
+		}
+		// the hash of older entries covers LegacyAction, what was derived from it must agree
+		if l.LegacyAction != "" {
+			if a, ok := entity.NormalizeAction(l.LegacyAction); !ok || a != l.Action || a.ResourceType() != l.ResourceType {
+				return broken("action does not match the legacy action it was normalized from")
+			}
+		}
+		for _, h := range anchors[l.Sequence] {
+			v.Anchors++
+			if h != l.Hash {
+				return broken("hash does not match the anchor, the chain was rewritten")
+			}
+		}
+		v.Checked++
+		v.LastSequence = l.Sequence
+		v.LastHash = l.Hash
+		return nil
+	}
+
+	archives, err := s.archiveRepo.ListAuditArchives(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range archives {
+		entries, err := s.store.Read(ctx, a)
+		if err != nil {
+			return fail(a.FirstSequence, err.Error())
+		}
+		v.Archives++
+		for i := range entries {
+			if v.Broken = check(&entries[i]); v.Broken != nil {
+				return v, nil
+			}
+		}
+	}
+
+	for {
+		batch, err := s.chainRepo.ListLogChain(ctx, v.LastSequence, chainBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for i := range batch {
+			if v.Broken = check(&batch[i]); v.Broken != nil {
+				return v, nil
+			}
+		}
+		if len(batch) < chainBatchSize {
+			break
+		}
+	}
+
+	for seq := range anchors {
+		if seq > v.LastSequence {
+			return fail(seq, fmt.Sprintf("anchor at %d is past the end of the chain (%d), entries were removed", seq, v.LastSequence))
+		}
+	}
+
+	v.Valid = true
+	return v, nil
+}
+
+// CreateAnchor checkpoints the current head of the chain, nothing new is
+// stored when the head is already anchored
+func (s *auditService) CreateAnchor(ctx context.Context) (*entity.AuditAnchor, error) {
+	head, err := s.chainRepo.GetLogChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	anchors, err := s.chainRepo.ListAuditAnchors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if n := len(anchors); n > 0 && anchors[n-1].Sequence == head.Sequence {
+		return &anchors[n-1], nil
+	}
+
+	a := &entity.AuditAnchor{Sequence: head.Sequence, Hash: head.Hash, CreatedAt: time.Now()}
+	if err := s.chainRepo.CreateAuditAnchor(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *auditService) ListAnchors(ctx context.Context) ([]entity.AuditAnchor, error) {
+	return s.chainRepo.ListAuditAnchors(ctx)
+}
+
+// GetAuditTrail lists every entry of the resource, oldest first, the
+// archived ones included
+func (s *auditService) GetAuditTrail(ctx context.Context, resourceID string) ([]entity.Log, error) {
+	archives, err := s.archiveRepo.ListAuditArchives(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var logs []entity.Log
+	for _, a := range archives {
+		entries, err := s.store.Read(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range entries {
+			if l.ResourceID == resourceID {
+				logs = append(logs, l)
+			}
+		}
+	}
+
+	live, err := s.logRepo.GetLogByResource(ctx, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	return append(logs, live...), nil
+}
+
+// GetLogDetails looks the entry up in the database, then in the archives
+func (s *auditService) GetLogDetails(ctx context.Context, logID string) (entity.Log, error) {
+	l, err := s.logRepo.GetLogByID(ctx, logID)
+	var nerr *entity.NotFoundError
+	if !errors.As(err, &nerr) {
+		return l, err
+	}
+
+	archives, err := s.archiveRepo.ListAuditArchives(ctx)
+	if err != nil {
+		return entity.Log{}, err
+	}
+	for i := len(archives) - 1; i >= 0; i-- {
+		entries, err := s.store.Read(ctx, archives[i])
+		if err != nil {
+			return entity.Log{}, err
+		}
+		for _, a := range entries {
+			if a.ID.String() == logID {
+				return a, nil
+			}
+		}
+	}
+	return entity.Log{}, nerr
+}
+
+func (s *auditService) SearchLogs(ctx context.Context, filter entity.LogFilter) (*entity.LogPage, error) {
+	if filter.Limit < 1 || filter.Limit > maxLogPageSize {
+		filter.Limit = 50
+	}
+	if err := s.validateFilter(&filter); err != nil {
+		return nil, err
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, &entity.ValidationError{Field: "from", Reason: "must be before to"}
+	}
+
+	// one extra row tells whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+	logs, err := s.logRepo.SearchLogs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) < filter.Limit {
+		archived, err := s.searchArchives(ctx, filter, filter.Limit-len(logs))
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, archived...)
+	}
+
+	page := &entity.LogPage{Entries: logs}
+	if len(logs) > limit {
+		page.Entries = logs[:limit]
+		page.NextCursor = strconv.FormatInt(page.Entries[limit-1].Sequence, 10)
+	}
+	return page, nil
+}
+
+// validateFilter checks the resource type and action of a filter, legacy
+// action names are swapped for their catalog ones so they still match
+func (s *auditService) validateFilter(filter *entity.LogFilter) error {
+	if filter.ResourceType != "" && !filter.ResourceType.Valid() {
+		return &entity.ValidationError{Field: "resource_type", Reason: "must be business, merchant, transaction or terminal"}
+	}
+	if filter.Action != "" {
+		a, ok := entity.NormalizeAction(string(filter.Action))
+		if !ok {
+			return &entity.ValidationError{Field: "action", Reason: "is not in the audit action catalog"}
+		}
+		filter.Action = a
+	}
+	return nil
+}
+
+// searchArchives returns up to n matching archived entries, newest first.
+// Archives are only read on request or when the range starts before the
+// newest archived entry.
+func (s *auditService) searchArchives(ctx context.Context, filter entity.LogFilter, n int) ([]entity.Log, error) {
+	archives, err := s.archiveRepo.ListAuditArchives(ctx)
+	if err != nil || len(archives) == 0 {
+		return nil, err
+	}
+	if !filter.Archived && (filter.From == nil || !filter.From.Before(archives[len(archives)-1].To)) {
+		return nil, nil
+	}
+
+	var found []entity.Log
+	for i := len(archives) - 1; i >= 0 && len(found) < n; i-- {
+		a := archives[i]
+		if filter.Before > 0 && a.FirstSequence >= filter.Before || !a.Covers(filter.From, filter.To) {
+			continue
+		}
+		entries, err := s.store.Read(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+		for j := len(entries) - 1; j >= 0 && len(found) < n; j-- {
+			if filter.Match(&entries[j]) {
+				found = append(found, entries[j])
+			}
+		}
+	}
+	return found, nil
+}
+
+// ArchiveLogs writes the entries older than cutoff to the store, one file per
+// archiveBatchSize entries, then removes them from the database
+func (s *auditService) ArchiveLogs(ctx context.Context, cutoff time.Time) ([]entity.AuditArchive, error) {
+	var archives []entity.AuditArchive
+	for {
+		entries, err := s.archiveRepo.ListArchivableLogs(ctx, cutoff, archiveBatchSize)
+		if err != nil || len(entries) == 0 {
+			return archives, err
+		}
+
+		file, sum, err := s.store.Write(ctx, entries)
+		if err != nil {
+			return archives, fmt.Errorf("write archive: %w", err)
+		}
+		a := entity.AuditArchive{
+			ID:            uuid.New(),
+			File:          file,
+			FirstSequence: entries[0].Sequence,
+			LastSequence:  entries[len(entries)-1].Sequence,
+			From:          entries[0].Timestamp,
+			To:            entries[0].Timestamp,
+			Entries:       len(entries),
+			SHA256:        sum,
+			CreatedAt:     time.Now(),
+		}
+		for _, l := range entries {
+			if l.Timestamp.Before(a.From) {
+				a.From = l.Timestamp
+			}
+			if l.Timestamp.After(a.To) {
+				a.To = l.Timestamp
+			}
+		}
+		if err := s.archiveRepo.SaveAuditArchive(ctx, &a); err != nil {
+			return archives, err
+		}
+		archives = append(archives, a)
+
+		if len(entries) < archiveBatchSize {
+			return archives, nil
+		}
+	}
+}
+
+func (s *auditService) ListArchives(ctx context.Context) ([]entity.AuditArchive, error) {
+	return s.archiveRepo.ListAuditArchives(ctx)
+}
+
+func (s *auditService) ExportBundle(ctx context.Context, actor string, filter entity.LogFilter, w io.Writer) (*entity.BundleManifest, error) {
+	if s.bundles == nil {
+		return nil, &entity.RejectedError{Code: entity.CodeExportDisabled, Reason: "no audit signing key is configured"}
+	}
+	if filter.From == nil && filter.To == nil && filter.ResourceID == "" {
+		return nil, &entity.ValidationError{Field: "from", Reason: "a time range or a resource_id is required"}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, &entity.ValidationError{Field: "from", Reason: "must be before to"}
+	}
+	tooLarge := &entity.ValidationError{Field: "from", Reason: fmt.Sprintf("more than %d entries, narrow the range", maxBundleEntries)}
+
+	// archived entries are the oldest ones
+	var entries []entity.Log
+	archives, err := s.archiveRepo.ListAuditArchives(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range archives {
+		if !a.Covers(filter.From, filter.To) {
+			continue
+		}
+		archived, err := s.store.Read(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+		for i := range archived {
+			if filter.Match(&archived[i]) {
+				entries = append(entries, archived[i])
+			}
+		}
+		if len(entries) > maxBundleEntries {
+			return nil, tooLarge
+		}
+	}
+
+	// the database is searched newest first
+	var live []entity.Log
+	filter.Limit = chainBatchSize
+	for {
+		batch, err := s.logRepo.SearchLogs(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		live = append(live, batch...)
+		if len(entries)+len(live) > maxBundleEntries {
+			return nil, tooLarge
+		}
+		if len(batch) < filter.Limit {
+			break
+		}
+		filter.Before = batch[len(batch)-1].Sequence
+	}
+	for i := len(live) - 1; i >= 0; i-- {
+		entries = append(entries, live[i])
+	}
+
+	m := &entity.BundleManifest{
+		ID:         uuid.New(),
+		CreatedAt:  time.Now(),
+		ExportedBy: actor,
+		From:       filter.From,
+		To:         filter.To,
+		ResourceID: filter.ResourceID,
+		Entries:    len(entries),
+	}
+	if n := len(entries); n > 0 {
+		m.FirstSequence = entries[0].Sequence
+		m.LastSequence = entries[n-1].Sequence
+		m.Contiguous = m.LastSequence-m.FirstSequence+1 == int64(n)
+	}
+	if err := s.bundles.WriteBundle(w, m, entries); err != nil {
+		return nil, fmt.Errorf("write bundle: %w", err)
+	}
+	return m, nil
+}
+
+func (s *auditService) GetBusinessAsOf(ctx context.Context, id uuid.UUID, at time.Time) (*entity.HistoricalBusiness, error) {
+	l, archived, err := s.snapshotAsOf(ctx, entity.ResourceBusiness, id, at)
+	if err != nil {
+		return nil, err
+	}
+	var b entity.Business
+	if err := json.Unmarshal(l.After, &b); err != nil {
+		return nil, fmt.Errorf("audit entry %d: %w", l.Sequence, err)
+	}
+	return &entity.HistoricalBusiness{Business: &b, AsOf: at, Source: entity.NewAuditSource(l, archived)}, nil
+}
+
+func (s *auditService) GetMerchantAsOf(ctx context.Context, id uuid.UUID, at time.Time) (*entity.HistoricalMerchant, error) {
+	l, archived, err := s.snapshotAsOf(ctx, entity.ResourceMerchant, id, at)
+	if err != nil {
+		return nil, err
+	}
+	var m entity.Merchant
+	if err := json.Unmarshal(l.After, &m); err != nil {
+		return nil, fmt.Errorf("audit entry %d: %w", l.Sequence, err)
+	}
+	return &entity.HistoricalMerchant{Merchant: &m, AsOf: at, Source: entity.NewAuditSource(l, archived)}, nil
+}
+
+// snapshotAsOf finds the last entry written for the resource before at whose
+// After snapshot is the resource, reading the archives when the database has
+// none. It tells whether the entry came from an archive.
+func (s *auditService) snapshotAsOf(ctx context.Context, rt entity.ResourceType, id uuid.UUID, at time.Time) (*entity.Log, bool, error) {
+	filter := entity.LogFilter{ResourceID: id.String(), ResourceType: rt, To: &at, Limit: chainBatchSize}
+
+	// pick tells whether the search ends at l, entries written before the
+	// snapshots were kept cannot be rebuilt
+	pick := func(l *entity.Log) (bool, error) {
+		if !l.Action.SnapshotsResource() {
+			return false, nil
+		}
+		if len(l.After) == 0 {
+			return true, &entity.RejectedError{Code: entity.CodeHistoryUnavailable, Reason: fmt.Sprintf("audit entry %d predates the audit snapshots", l.Sequence)}
+		}
+		return true, nil
+	}
+
+	for {
+		batch, err := s.logRepo.SearchLogs(ctx, filter)
+		if err != nil {
+			return nil, false, err
+		}
+		for i := range batch {
+			if done, err := pick(&batch[i]); done {
+				return &batch[i], false, err
+			}
+		}
+		if len(batch) < filter.Limit {
+			break
+		}
+		filter.Before = batch[len(batch)-1].Sequence
+	}
+
+	archives, err := s.archiveRepo.ListAuditArchives(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	for i := len(archives) - 1; i >= 0; i-- {
+		if !archives[i].Covers(nil, &at) {
+			continue
+		}
+		entries, err := s.store.Read(ctx, archives[i])
+		if err != nil {
+			return nil, false, err
+		}
+		for j := len(entries) - 1; j >= 0; j-- {
+			if !filter.Match(&entries[j]) {
+				continue
+			}
+			if done, err := pick(&entries[j]); done {
+				return &entries[j], true, err
+			}
+		}
+	}
+	return nil, false, fmt.Errorf("no audit entry for %s %s before %s", rt, id, at.Format(time.RFC3339))
+}
+
+func (s *auditService) StreamLogs(ctx context.Context, filter entity.LogFilter, afterSeq int64, send func(l *entity.Log) error) error {
+	if err := s.validateFilter(&filter); err != nil {
+		return err
+	}
+
+	// subscribe before catching up, nothing committed in between is missed
+	wake, stop := s.feed.SubscribeLogs()
+	defer stop()
+
+	if afterSeq < 0 {
+		head, err := s.logRepo.SearchLogs(ctx, entity.LogFilter{Limit: 1})
+		if err != nil {
+			return err
+		}
+		afterSeq = 0
+		if len(head) > 0 {
+			afterSeq = head[0].Sequence
+		}
+	}
+	if err := send(nil); err != nil {
+		return err
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		for {
+			batch, err := s.chainRepo.ListLogChain(ctx, afterSeq, chainBatchSize)
+			if err != nil {
+				return err
+			}
+			for i := range batch {
+				afterSeq = batch[i].Sequence
+				if !filter.Match(&batch[i]) {
+					continue
+				}
+				if err := send(&batch[i]); err != nil {
+					return err
+				}
+			}
+			if len(batch) < chainBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wake:
+		case <-heartbeat.C:
+			if err := send(nil); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/google/uuid"
+)
+
+// IVA rate applied to the commissions we charge, in basis points (16%)
+const invoiceTaxRate = 1600
+
+type invoiceService struct {
+	issuer   entity.InvoiceIssuer
+	bizRepo  BusinessRepository
+	txRepo   TransactionRepository
+	logRepo  LogRepository
+	renderer InvoiceRenderer
+	stamper  InvoiceStamper
+}
+
+func NewInvoiceService(issuer entity.InvoiceIssuer, br BusinessRepository, tr TransactionRepository, lr LogRepository, r InvoiceRenderer, st InvoiceStamper) InvoiceUseCase {
+	return &invoiceService{
+		issuer:   issuer,
+		bizRepo:  br,
+		txRepo:   tr,
+		logRepo:  lr,
+		renderer: r,
+		stamper:  st,
+	}
+}
+
+// GenerateCommissionInvoice bills a business for the commissions charged on
+// its merchants' transactions in [from, to)
+func (s *invoiceService) GenerateCommissionInvoice(ctx context.Context, actor string, businessID uuid.UUID, from, to time.Time) (*entity.Invoice, error) {
+	if !from.Before(to) {
+		return nil, &entity.ValidationError{Field: "to", Reason: "billing period ends before it starts"}
+	}
+
+	biz, err := s.bizRepo.GetBusinessByID(ctx, businessID)
+	if err != nil {
+		return nil, err
+	}
+	if !biz.FiscalData.Complete() {
+		return nil, &entity.RejectedError{Code: entity.CodeFiscalDataIncomplete, Reason: "legal name, RFC, tax regime and postal code are required"}
+	}
+
+	// attributed by owner at processing time, transferred merchants are billed
+	// to each business for its own period
+	txs, err := s.txRepo.TransactionListByBusinessBetween(ctx, businessID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	var merchants []uuid.UUID
+	byMerchant := map[uuid.UUID][]entity.Transaction{}
+	for _, t := range txs {
+		if _, ok := byMerchant[t.MerchantID]; !ok {
+			merchants = append(merchants, t.MerchantID)
+		}
+		byMerchant[t.MerchantID] = append(byMerchant[t.MerchantID], t)
+	}
+
+	// the issue time (to the millisecond) keeps the folios of a regenerated
+	// period apart: period-business-issued, at most 35 of the 40 characters
+	issuedAt := time.Now()
+	inv := &entity.Invoice{
+		BusinessID:  businessID,
+		Folio:       from.Format("20060102") + "-" + biz.ID.String()[:8] + "-" + strings.Replace(issuedAt.UTC().Format("20060102150405.000"), ".", "", 1),
+		PeriodStart: from,
+		PeriodEnd:   to,
+		IssuedAt:    issuedAt,
+		Issuer:      s.issuer,
+		Receiver:    *biz,
+	}
+
+	for _, mID := range merchants {
+		txs := byMerchant[mID]
+
+		var fees int64
+		for _, t := range txs {
+			fees += t.Fee
+		}
+		if fees == 0 {
+			continue
+		}
+		// the CFDI amounts cannot be negative (minInclusive 0), money owed
+		// back to the business is a credit note, not an income invoice
+		if fees < 0 {
+			return nil, &entity.RejectedError{
+				Code:   entity.CodeNegativeInvoice,
+				Reason: fmt.Sprintf("commissions of merchant %s total %s in the requested period", mID, entity.FormatCents(fees)),
+			}
+		}
+
+		tax := (fees*invoiceTaxRate + 5000) / 10000
+		inv.Concepts = append(inv.Concepts, entity.InvoiceConcept{
+			MerchantID:       mID,
+			Description:      fmt.Sprintf("Comisiones por procesamiento de %d transacciones del comercio %s", len(txs), mID),
+			TransactionCount: len(txs),
+			Amount:           fees,
+			Tax:              tax,
+		})
+		inv.Subtotal += fees
+		inv.Tax += tax
+	}
+
+	if len(inv.Concepts) == 0 {
+		return nil, &entity.RejectedError{Code: entity.CodeNothingToInvoice, Reason: "no commissions to invoice in the requested period"}
+	}
+	inv.Total = inv.Subtotal + inv.Tax
+
+	xml, err := s.renderer.Render(inv)
+	if err != nil {
+		return nil, fmt.Errorf("render invoice: %w", err)
+	}
+	inv.XML = xml
+
+	stamped, stamp, err := s.stamper.Stamp(ctx, xml)
+	if err != nil {
+		return nil, fmt.Errorf("stamp invoice: %w", err)
+	}
+	inv.XML = stamped
+	inv.Stamp = stamp
+
+	// the XML document is left out of the snapshot, it is returned to the caller
+	snapshot := *inv
+	snapshot.XML = nil
+	err = s.logRepo.CreateLog(ctx, &entity.Log{
+		ID:             uuid.New(),
+		Action:         entity.ActionBusinessInvoiced,
+		Actor:          actor,
+		ResourceID:     businessID.String(),
+		PrevResourceID: fmt.Sprintf("folio:%s", inv.Folio),
+		After:          entity.Snapshot(snapshot),
+		Timestamp:      time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	entity "github.com/CardenalDex/crudprotec/internal/entitys"
@@ -13,17 +14,32 @@ type merchantService struct {
 	repo    MerchantRepository
 	bizRepo BusinessRepository
 	logRepo LogRepository
+	uow     UnitOfWork
 }
 
-func NewMerchantService(r MerchantRepository, b BusinessRepository, l LogRepository) MerchantUseCase {
+func NewMerchantService(r MerchantRepository, b BusinessRepository, l LogRepository, uow UnitOfWork) MerchantUseCase {
 	return &merchantService{
 		repo:    r,
 		bizRepo: b,
 		logRepo: l,
+		uow:     uow,
 	}
 }
 
-func (s *merchantService) RegisterMerchant(ctx context.Context, actor string, businessID uuid.UUID) (*entity.Merchant, error) {
+// RegisterMerchant creates the merchant as active unless status says it is
+// still onboarding
+func (s *merchantService) RegisterMerchant(ctx context.Context, actor string, businessID uuid.UUID, profile entity.MerchantProfile, status entity.MerchantStatus) (*entity.Merchant, error) {
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	switch status {
+	case "":
+		status = entity.MerchantActive
+	case entity.MerchantOnboarding, entity.MerchantActive:
+	default:
+		return nil, &entity.ValidationError{Field: "status", Reason: "a new merchant must be onboarding or active"}
+	}
+
 	// 1. Validate Business existence
 	_, err := s.bizRepo.GetBusinessByID(ctx, businessID)
 	if err != nil {
@@ -32,54 +48,324 @@ func (s *merchantService) RegisterMerchant(ctx context.Context, actor string, bu
 
 	now := time.Now()
 	m := &entity.Merchant{
-		ID:         uuid.New(),
-		BusinessID: businessID,
-		CreatedAt:  now,
-		UpdatedAt:  now,
+		ID:              uuid.New(),
+		BusinessID:      businessID,
+		MerchantProfile: profile,
+		Status:          status,
+		CreatedAt:       now,
+		UpdatedAt:       now,
 	}
 
-	if err := s.repo.CreateMerchant(ctx, m); err != nil {
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateMerchant(ctx, m); err != nil {
+			return err
+		}
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:         uuid.New(),
+			Action:     entity.ActionMerchantRegistered,
+			Actor:      actor,
+			ResourceID: m.ID.String(),
+			After:      entity.Snapshot(m),
+			Timestamp:  now,
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	s.logRepo.CreateLog(ctx, &entity.Log{
-		ID:         uuid.New(),
-		Action:     "MERCHANT_REGISTERED",
-		Actor:      actor,
-		ResourceID: m.ID.String(),
-		Timestamp:  now,
+	return m, nil
+}
+
+func (s *merchantService) GetMerchant(ctx context.Context, id uuid.UUID, includeDeleted bool) (*entity.Merchant, error) {
+	if includeDeleted {
+		return s.repo.GetMerchantIncludingDeleted(ctx, id)
+	}
+	return s.repo.GetMerchantByID(ctx, id)
+}
+
+func (s *merchantService) GetBusinessMerchants(ctx context.Context, businessID uuid.UUID, includeDeleted bool) ([]entity.Merchant, error) {
+	return s.repo.GetMerchantByBusinessID(ctx, businessID, includeDeleted)
+}
+
+// UpdateMerchantProfile applies a partial update, nothing is saved or audited
+// when no field actually changes
+func (s *merchantService) UpdateMerchantProfile(ctx context.Context, actor string, id uuid.UUID, patch entity.MerchantProfilePatch) (*entity.Merchant, error) {
+	var m *entity.Merchant
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if m, err = s.repo.GetMerchantByID(ctx, id); err != nil {
+			return errors.New("merchant not found")
+		}
+
+		before := entity.Snapshot(m)
+		p := &m.MerchantProfile
+		changed := false
+		setField := func(dst *string, v *string) {
+			if v != nil && *v != *dst {
+				*dst, changed = *v, true
+			}
+		}
+		setField(&p.DisplayName, patch.DisplayName)
+		setField(&p.MCC, patch.MCC)
+		setField(&p.ContactEmail, patch.ContactEmail)
+		setField(&p.Phone, patch.Phone)
+		if patch.Location != nil && *patch.Location != p.Location {
+			p.Location, changed = *patch.Location, true
+		}
+
+		if !changed {
+			return nil
+		}
+		if err := p.Validate(); err != nil {
+			return err
+		}
+
+		m.UpdatedAt = time.Now()
+		if err := s.repo.UpdateMerchant(ctx, m); err != nil {
+			return err
+		}
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:         uuid.New(),
+			Action:     entity.ActionMerchantProfileUpdated,
+			Actor:      actor,
+			ResourceID: id.String(),
+			Before:     before,
+			After:      entity.Snapshot(m),
+			Timestamp:  m.UpdatedAt,
+		})
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return m, nil
 }
 
-func (s *merchantService) GetMerchant(ctx context.Context, id uuid.UUID) (*entity.Merchant, error) {
-	return s.repo.GetMerchantByID(ctx, id)
+// ChangeMerchantStatus moves the merchant through its lifecycle, only active
+// merchants can process transactions
+func (s *merchantService) ChangeMerchantStatus(ctx context.Context, actor string, id uuid.UUID, status entity.MerchantStatus, reason string) (*entity.Merchant, error) {
+	if reason == "" {
+		return nil, &entity.ValidationError{Field: "reason", Reason: "is required"}
+	}
+
+	var m *entity.Merchant
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if m, err = s.repo.GetMerchantByID(ctx, id); err != nil {
+			return errors.New("merchant not found")
+		}
+		if err := m.Status.CheckTransition(status); err != nil {
+			return err
+		}
+
+		before := entity.Snapshot(m)
+		old := m.Status
+		now := time.Now()
+		m.Status = status
+		m.StatusReason = reason
+		m.StatusChangedAt = &now
+		m.UpdatedAt = now
+		if err := s.repo.UpdateMerchant(ctx, m); err != nil {
+			return err
+		}
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:             uuid.New(),
+			Action:         entity.ActionMerchantStatusChanged,
+			Actor:          actor,
+			ResourceID:     id.String(),
+			PrevResourceID: fmt.Sprintf("status:%s", old),
+			Before:         before,
+			After:          entity.Snapshot(m),
+			Timestamp:      now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
 }
 
-func (s *merchantService) GetBusinessMerchants(ctx context.Context, businessID uuid.UUID) ([]entity.Merchant, error) {
-	return s.repo.GetMerchantByBusinessID(ctx, businessID)
+// SetCommissionOverride gives the merchant its own rate, a nil override
+// brings it back to the business rate
+func (s *merchantService) SetCommissionOverride(ctx context.Context, actor string, id uuid.UUID, override *entity.CommissionOverride) (*entity.Merchant, error) {
+	now := time.Now()
+	if override != nil {
+		if override.Rate < 0 {
+			return nil, &entity.ValidationError{Field: "commission_percentage", Reason: "cannot be negative"}
+		}
+		if override.ExpiresAt != nil && !override.ExpiresAt.After(now) {
+			return nil, &entity.ValidationError{Field: "expires_at", Reason: "must be in the future"}
+		}
+	}
+
+	var m *entity.Merchant
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if m, err = s.repo.GetMerchantByID(ctx, id); err != nil {
+			return errors.New("merchant not found")
+		}
+
+		before := entity.Snapshot(m)
+		old := "none"
+		if o := m.CommissionOverride; o != nil {
+			old = fmt.Sprintf("%d", o.Rate)
+			if o.ExpiresAt != nil {
+				old += "@" + o.ExpiresAt.UTC().Format(time.RFC3339)
+			}
+		}
+		m.CommissionOverride = override
+		m.UpdatedAt = now
+		if err := s.repo.UpdateMerchant(ctx, m); err != nil {
+			return err
+		}
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:             uuid.New(),
+			Action:         entity.ActionMerchantCommissionUpdated,
+			Actor:          actor,
+			ResourceID:     id.String(),
+			PrevResourceID: fmt.Sprintf("old_comm:%s", old),
+			Before:         before,
+			After:          entity.Snapshot(m),
+			Timestamp:      now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
 }
 
 func (s *merchantService) RemoveMerchant(ctx context.Context, actor string, id uuid.UUID) error {
-	// Check if exists before deleting for better error handling
-	_, err := s.repo.GetMerchantByID(ctx, id)
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		// Check if exists before deleting for better error handling
+		m, err := s.repo.GetMerchantByID(ctx, id)
+		if err != nil {
+			return errors.New("merchant not found")
+		}
+
+		if err := s.repo.DeleteMerchant(ctx, id); err != nil {
+			return err
+		}
+		deleted, _ := s.repo.GetMerchantIncludingDeleted(ctx, id)
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:         uuid.New(),
+			Action:     entity.ActionMerchantDeleted,
+			Actor:      actor,
+			ResourceID: id.String(),
+			Before:     entity.Snapshot(m),
+			After:      entity.Snapshot(deleted),
+			Timestamp:  time.Now(),
+		})
+	})
 	if err != nil {
-		return errors.New("merchant not found")
+		return err
 	}
 
-	if err := s.repo.DeleteMerchant(ctx, id); err != nil {
-		return err
+	return nil
+}
+
+// RestoreMerchant undoes a soft delete, the business must still be alive
+func (s *merchantService) RestoreMerchant(ctx context.Context, actor string, id uuid.UUID, reason string) (*entity.Merchant, error) {
+	if reason == "" {
+		return nil, &entity.ValidationError{Field: "reason", Reason: "is required"}
+	}
+
+	var restored *entity.Merchant
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		m, err := s.repo.GetMerchantIncludingDeleted(ctx, id)
+		if err != nil {
+			return errors.New("merchant not found")
+		}
+		if m.DeletedAt == nil {
+			return &entity.RejectedError{Code: entity.CodeNotDeleted, Reason: "merchant is not deleted"}
+		}
+		if _, err := s.bizRepo.GetBusinessByID(ctx, m.BusinessID); err != nil {
+			return &entity.RejectedError{Code: entity.CodeParentDeleted, Reason: "business " + m.BusinessID.String() + " is deleted, restore it first"}
+		}
+
+		if err := s.repo.RestoreMerchant(ctx, id); err != nil {
+			return err
+		}
+		restored, err = s.repo.GetMerchantByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:             uuid.New(),
+			Action:         entity.ActionMerchantRestored,
+			Actor:          actor,
+			ResourceID:     id.String(),
+			PrevResourceID: fmt.Sprintf("reason:%s", reason),
+			Before:         entity.Snapshot(m),
+			After:          entity.Snapshot(restored),
+			Timestamp:      time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// TransferMerchant moves the merchant to another business from effectiveAt
+// (now when zero) on. It cannot be in the future nor before the current
+// ownership started.
+func (s *merchantService) TransferMerchant(ctx context.Context, actor string, id, toBusinessID uuid.UUID, effectiveAt time.Time) (*entity.MerchantTransfer, error) {
+	now := time.Now()
+	if effectiveAt.IsZero() {
+		effectiveAt = now
+	}
+	if effectiveAt.After(now) {
+		return nil, &entity.ValidationError{Field: "effective_at", Reason: "cannot be in the future"}
 	}
 
-	// Audit Log
-	s.logRepo.CreateLog(ctx, &entity.Log{
-		ID:         uuid.New(),
-		Action:     "MERCHANT_DELETED",
-		Actor:      actor,
-		ResourceID: id.String(),
-		Timestamp:  time.Now(),
+	var t *entity.MerchantTransfer
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		m, err := s.repo.GetMerchantByID(ctx, id)
+		if err != nil {
+			return errors.New("merchant not found")
+		}
+		if m.BusinessID == toBusinessID {
+			return &entity.ValidationError{Field: "business_id", Reason: "merchant already belongs to this business"}
+		}
+		if _, err := s.bizRepo.GetBusinessByID(ctx, toBusinessID); err != nil {
+			return &entity.ValidationError{Field: "business_id", Reason: "business does not exist"}
+		}
+
+		t = &entity.MerchantTransfer{
+			MerchantID:     id,
+			FromBusinessID: m.BusinessID,
+			ToBusinessID:   toBusinessID,
+			EffectiveAt:    effectiveAt,
+		}
+		if err := s.repo.TransferMerchant(ctx, t); err != nil {
+			return err
+		}
+		moved, _ := s.repo.GetMerchantByID(ctx, id)
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:             uuid.New(),
+			Action:         entity.ActionMerchantTransferred,
+			Actor:          actor,
+			ResourceID:     id.String(),
+			PrevResourceID: fmt.Sprintf("business:%s", m.BusinessID),
+			Before:         entity.Snapshot(m),
+			After:          entity.Snapshot(moved),
+			Timestamp:      now,
+		})
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	return t, nil
+}
+
+func (s *merchantService) GetMerchantOwnership(ctx context.Context, id uuid.UUID) ([]entity.MerchantOwnership, error) {
+	if _, err := s.repo.GetMerchantIncludingDeleted(ctx, id); err != nil {
+		return nil, errors.New("merchant not found")
+	}
+	return s.repo.ListMerchantOwnership(ctx, id)
 }
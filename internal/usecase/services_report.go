@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/google/uuid"
+)
+
+const maxLeaderboardSize = 100
+
+type reportService struct {
+	repo    ReportRepository
+	bizRepo BusinessRepository
+}
+
+func NewReportService(r ReportRepository, br BusinessRepository) ReportUseCase {
+	return &reportService{repo: r, bizRepo: br}
+}
+
+// Leaderboard ranks merchants or businesses by metric over [from, to) and
+// compares each one against the previous period of the same length.
+// With byDecline the ranking is by change ascending, biggest drops first.
+func (s *reportService) Leaderboard(ctx context.Context, group entity.ReportGroup, metric entity.ReportMetric, from, to time.Time, limit int, byDecline bool) (*entity.Leaderboard, error) {
+	switch group {
+	case entity.GroupByMerchant, entity.GroupByBusiness, entity.GroupByRoot, entity.GroupByTerminal:
+	default:
+		return nil, errors.New("group must be 'merchant', 'business', 'business_group' or 'terminal'")
+	}
+	switch metric {
+	case entity.MetricVolume, entity.MetricFees, entity.MetricCount:
+	default:
+		return nil, errors.New("metric must be 'volume', 'fees' or 'count'")
+	}
+	if !from.Before(to) {
+		return nil, errors.New("invalid period")
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > maxLeaderboardSize {
+		limit = maxLeaderboardSize
+	}
+
+	prevTo := from
+	prevFrom := from.Add(-to.Sub(from))
+
+	current, err := s.repo.AggregateTransactions(ctx, group, from, to)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.repo.AggregateTransactions(ctx, group, prevFrom, prevTo)
+	if err != nil {
+		return nil, err
+	}
+
+	rankRows(current, metric)
+	rankRows(previous, metric)
+
+	prevRank := make(map[uuid.UUID]int, len(previous))
+	prevValue := make(map[uuid.UUID]int64, len(previous))
+	for i, row := range previous {
+		prevRank[row.ID] = i + 1
+		prevValue[row.ID] = row.Value(metric)
+	}
+
+	entries := make([]entity.LeaderboardEntry, 0, len(current))
+	seen := make(map[uuid.UUID]bool, len(current))
+	for _, row := range current {
+		seen[row.ID] = true
+		entries = append(entries, newLeaderboardEntry(row, metric, prevValue, prevRank))
+	}
+	if byDecline {
+		// whoever went silent this period is the steepest drop of all
+		for _, row := range previous {
+			if !seen[row.ID] {
+				entries = append(entries, newLeaderboardEntry(entity.AggregateRow{ID: row.ID}, metric, prevValue, prevRank))
+			}
+		}
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Change < entries[j].Change })
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+
+	board := &entity.Leaderboard{
+		Group:        group,
+		Metric:       metric,
+		From:         from,
+		To:           to,
+		PreviousFrom: prevFrom,
+		PreviousTo:   prevTo,
+		Entries:      entries,
+	}
+
+	return board, nil
+}
+
+// BusinessRollup consolidates the business and all its descendants over
+// [from, to), each node carries its own figures and its subtree total
+func (s *reportService) BusinessRollup(ctx context.Context, businessID uuid.UUID, from, to time.Time) (*entity.BusinessRollup, error) {
+	if !from.Before(to) {
+		return nil, &entity.ValidationError{Field: "from", Reason: "must be before to"}
+	}
+
+	nodes, err := s.bizRepo.ListBusinessTree(ctx, businessID)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, &entity.NotFoundError{Resource: "business"}
+	}
+	rows, err := s.repo.AggregateTransactions(ctx, entity.GroupByBusiness, from, to)
+	if err != nil {
+		return nil, err
+	}
+	totals := make(map[uuid.UUID]entity.AggregateRow, len(rows))
+	for _, row := range rows {
+		totals[row.ID] = row
+	}
+
+	// nodes come parents first, so every parent exists before its children
+	byID := make(map[uuid.UUID]*entity.BusinessRollup, len(nodes))
+	for _, n := range nodes {
+		row := totals[n.ID]
+		r := &entity.BusinessRollup{
+			BusinessID: n.ID,
+			ParentID:   n.ParentID,
+			LegalName:  n.LegalName,
+			TradeName:  n.TradeName,
+			Depth:      n.Depth,
+			Own:        entity.RollupTotals{Merchants: n.MerchantCount, Count: row.Count, Volume: row.Volume, Fees: row.Fees},
+			Children:   []*entity.BusinessRollup{},
+		}
+		byID[n.ID] = r
+		if n.Depth > 0 {
+			parent := byID[*n.ParentID]
+			parent.Children = append(parent.Children, r)
+		}
+	}
+	// and children last, so walking backwards totals every subtree
+	for i := len(nodes) - 1; i >= 0; i-- {
+		r := byID[nodes[i].ID]
+		r.Total.Add(r.Own)
+		if r.Depth > 0 {
+			byID[*r.ParentID].Total.Add(r.Total)
+		}
+	}
+
+	return byID[businessID], nil
+}
+
+func newLeaderboardEntry(row entity.AggregateRow, metric entity.ReportMetric, prevValue map[uuid.UUID]int64, prevRank map[uuid.UUID]int) entity.LeaderboardEntry {
+	value := row.Value(metric)
+	prev := prevValue[row.ID]
+	entry := entity.LeaderboardEntry{
+		ID:            row.ID,
+		Count:         row.Count,
+		Volume:        row.Volume,
+		Fees:          row.Fees,
+		Value:         value,
+		PreviousValue: prev,
+		PreviousRank:  prevRank[row.ID],
+		Change:        value - prev,
+	}
+	if prev != 0 {
+		pct := float64(entry.Change) * 100 / float64(prev)
+		entry.ChangePct = &pct
+	}
+	return entry
+}
+
+// rankRows sorts by metric descending, ties broken by ID for stable ranks
+func rankRows(rows []entity.AggregateRow, metric entity.ReportMetric) {
+	sort.Slice(rows, func(i, j int) bool {
+		vi, vj := rows[i].Value(metric), rows[j].Value(metric)
+		if vi != vj {
+			return vi > vj
+		}
+		return rows[i].ID.String() < rows[j].ID.String()
+	})
+}
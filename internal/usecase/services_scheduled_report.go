@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/google/uuid"
+)
+
+type scheduledReportService struct {
+	txRepo      TransactionRepository
+	summaryRepo SummaryRepository
+	runRepo     ReportRunRepository
+	outbox      OutboxWriter
+}
+
+func NewScheduledReportService(tr TransactionRepository, sr SummaryRepository, rr ReportRunRepository, o OutboxWriter) ScheduledReportUseCase {
+	return &scheduledReportService{txRepo: tr, summaryRepo: sr, runRepo: rr, outbox: o}
+}
+
+func (s *scheduledReportService) RunReport(ctx context.Context, report entity.ScheduledReport, scheduledFor time.Time, attempt int) (*entity.ReportRun, error) {
+	var build func(ctx context.Context, from, to time.Time) ([]entity.ReportTable, error)
+	switch report {
+	case entity.ReportDailyRevenue:
+		build = s.dailyRevenue
+	case entity.ReportMerchantStatements:
+		build = s.merchantStatements
+	case entity.ReportReconciliation:
+		build = s.reconciliation
+	default:
+		return nil, &entity.ValidationError{Field: "report", Reason: "unknown report " + strconv.Quote(string(report))}
+	}
+
+	to := entity.SummaryDay(scheduledFor)
+	run := &entity.ReportRun{
+		ID:           uuid.New(),
+		Report:       report,
+		ScheduledFor: scheduledFor,
+		PeriodStart:  to.AddDate(0, 0, -1),
+		PeriodEnd:    to,
+		Attempt:      attempt,
+		Status:       entity.RunRunning,
+		StartedAt:    time.Now(),
+	}
+	if err := s.runRepo.CreateReportRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	err := func() error {
+		tables, err := build(ctx, run.PeriodStart, run.PeriodEnd)
+		if err != nil {
+			return err
+		}
+		dir, files, err := s.outbox.Write(ctx, run, tables)
+		if err != nil {
+			return fmt.Errorf("write outbox: %w", err)
+		}
+		run.OutputDir = dir
+		run.Files = len(files)
+		return nil
+	}()
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Status = entity.RunSucceeded
+	if err != nil {
+		run.Status = entity.RunFailed
+		run.Error = err.Error()
+	}
+	if uerr := s.runRepo.UpdateReportRun(ctx, run); uerr != nil && err == nil {
+		err = uerr
+	}
+
+	return run, err
+}
+
+func (s *scheduledReportService) ListReportRuns(ctx context.Context, report entity.ScheduledReport, status entity.ReportRunStatus, limit int) ([]entity.ReportRun, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	return s.runRepo.ListReportRuns(ctx, report, status, limit)
+}
+
+// --- Reports ---
+
+func (s *scheduledReportService) dailyRevenue(ctx context.Context, from, _ time.Time) ([]entity.ReportTable, error) {
+	summaries, err := s.summaryRepo.ListDailySummariesForDay(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	t := entity.ReportTable{
+		Name:   "daily_revenue",
+		Header: []string{"day", "merchant_id", "count", "gross", "fees", "refunds"},
+	}
+	var total entity.DailySummary
+	for _, sum := range summaries {
+		t.Rows = append(t.Rows, []string{
+			from.Format("2006-01-02"), sum.MerchantID.String(),
+			strconv.FormatInt(sum.Count, 10), entity.FormatCents(sum.Gross), entity.FormatCents(sum.Fees), entity.FormatCents(sum.Refunds),
+		})
+		total.Count += sum.Count
+		total.Gross += sum.Gross
+		total.Fees += sum.Fees
+		total.Refunds += sum.Refunds
+	}
+	t.Rows = append(t.Rows, []string{
+		from.Format("2006-01-02"), "TOTAL",
+		strconv.FormatInt(total.Count, 10), entity.FormatCents(total.Gross), entity.FormatCents(total.Fees), entity.FormatCents(total.Refunds),
+	})
+	return []entity.ReportTable{t}, nil
+}
+
+// merchantStatements writes one statement per merchant with activity
+func (s *scheduledReportService) merchantStatements(ctx context.Context, from, to time.Time) ([]entity.ReportTable, error) {
+	txs, err := s.txRepo.GetTransactionsBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []entity.ReportTable
+	byMerchant := map[uuid.UUID]int{}
+	for _, tx := range txs {
+		i, ok := byMerchant[tx.MerchantID]
+		if !ok {
+			i = len(tables)
+			byMerchant[tx.MerchantID] = i
+			tables = append(tables, entity.ReportTable{
+				Name:   "statement_" + tx.MerchantID.String(),
+				Header: []string{"transaction_id", "timestamp", "amount", "commission_bp", "fee"},
+			})
+		}
+		tables[i].Rows = append(tables[i].Rows, []string{
+			tx.ID.String(), tx.Timestamp.UTC().Format(time.RFC3339),
+			entity.FormatCents(tx.Amount), strconv.FormatInt(tx.Commission, 10), entity.FormatCents(tx.Fee),
+		})
+	}
+	return tables, nil
+}
+
+// reconciliation checks the daily summaries against the raw transactions
+func (s *scheduledReportService) reconciliation(ctx context.Context, from, to time.Time) ([]entity.ReportTable, error) {
+	summaries, err := s.summaryRepo.ListDailySummariesForDay(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	txs, err := s.txRepo.GetTransactionsBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	type pair struct{ summary, raw entity.DailySummary }
+	rows := map[uuid.UUID]*pair{}
+	var order []uuid.UUID
+	get := func(id uuid.UUID) *pair {
+		p, ok := rows[id]
+		if !ok {
+			p = &pair{}
+			rows[id] = p
+			order = append(order, id)
+		}
+		return p
+	}
+	for _, sum := range summaries {
+		get(sum.MerchantID).summary = sum
+	}
+	for _, tx := range txs {
+		p := get(tx.MerchantID)
+		p.raw.Count++
+		p.raw.Gross += tx.Amount
+		p.raw.Fees += tx.Fee
+	}
+
+	t := entity.ReportTable{
+		Name: "reconciliation",
+		Header: []string{"merchant_id", "summary_count", "transactions_count", "summary_gross", "transactions_gross",
+			"summary_fees", "transactions_fees", "status"},
+	}
+	mismatches := 0
+	for _, id := range order {
+		p := rows[id]
+		status := "OK"
+		if p.summary.Count != p.raw.Count || p.summary.Gross != p.raw.Gross || p.summary.Fees != p.raw.Fees {
+			status = "MISMATCH"
+			mismatches++
+		}
+		t.Rows = append(t.Rows, []string{
+			id.String(),
+			strconv.FormatInt(p.summary.Count, 10), strconv.FormatInt(p.raw.Count, 10),
+			entity.FormatCents(p.summary.Gross), entity.FormatCents(p.raw.Gross),
+			entity.FormatCents(p.summary.Fees), entity.FormatCents(p.raw.Fees),
+			status,
+		})
+	}
+	t.Rows = append(t.Rows, []string{"TOTAL_MISMATCHES", "", "", "", "", "", "", strconv.Itoa(mismatches)})
+	return []entity.ReportTable{t}, nil
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/google/uuid"
+)
+
+type simulatorService struct {
+	bizRepo BusinessRepository
+	txRepo  TransactionRepository
+}
+
+func NewSimulatorService(br BusinessRepository, tr TransactionRepository) SimulatorUseCase {
+	return &simulatorService{bizRepo: br, txRepo: tr}
+}
+
+// SimulateCommission replays the business's transactions in [from, to) under
+// the proposed schedule. Transactions priced by a merchant override would not
+// follow the business rate, they keep the fee they were charged. Read only,
+// nothing is persisted or audited.
+func (s *simulatorService) SimulateCommission(ctx context.Context, businessID uuid.UUID, proposed entity.FeeSchedule, from, to time.Time) (*entity.CommissionSimulation, error) {
+	if !from.Before(to) {
+		return nil, errors.New("invalid period")
+	}
+	if proposed.Rate < 0 {
+		return nil, errors.New("commission cannot be negative")
+	}
+	for _, t := range proposed.Tiers {
+		if t.Rate < 0 || t.MinAmount < 0 {
+			return nil, errors.New("tier amount and commission cannot be negative")
+		}
+	}
+
+	biz, err := s.bizRepo.GetBusinessByID(ctx, businessID)
+	if err != nil {
+		return nil, errors.New("business not found")
+	}
+
+	txs, err := s.txRepo.TransactionListByBusinessBetween(ctx, businessID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	months := map[string]*entity.SimulationMonth{}
+	sim := &entity.CommissionSimulation{
+		BusinessID:        biz.ID,
+		CurrentCommission: biz.Commission,
+		Proposed:          proposed,
+		Months:            []entity.SimulationMonth{},
+	}
+
+	for _, t := range txs {
+		key := t.Timestamp.UTC().Format("2006-01")
+		month, ok := months[key]
+		if !ok {
+			month = &entity.SimulationMonth{Month: key}
+			months[key] = month
+		}
+		simulated := CalculateFee(t.Amount, proposed.RateFor(t.Amount))
+		if t.CommissionSource == entity.SourceMerchantOverride {
+			simulated = t.Fee
+			month.Overridden++
+		}
+
+		month.Count++
+		month.Volume += t.Amount
+		month.CurrentFees += t.Fee
+		month.SimulatedFees += simulated
+	}
+
+	for _, month := range months {
+		month.Difference = month.SimulatedFees - month.CurrentFees
+		sim.Months = append(sim.Months, *month)
+
+		sim.Total.Count += month.Count
+		sim.Total.Overridden += month.Overridden
+		sim.Total.Volume += month.Volume
+		sim.Total.CurrentFees += month.CurrentFees
+		sim.Total.SimulatedFees += month.SimulatedFees
+	}
+	sim.Total.Difference = sim.Total.SimulatedFees - sim.Total.CurrentFees
+	sort.Slice(sim.Months, func(i, j int) bool { return sim.Months[i].Month < sim.Months[j].Month })
+
+	return sim, nil
+}
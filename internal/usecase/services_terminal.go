@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	entity "github.com/CardenalDex/crudprotec/internal/entitys"
+	"github.com/google/uuid"
+)
+
+type terminalService struct {
+	repo         TerminalRepository
+	merchantRepo MerchantRepository
+	txRepo       TransactionRepository
+	logRepo      LogRepository
+	uow          UnitOfWork
+}
+
+func NewTerminalService(r TerminalRepository, mr MerchantRepository, tr TransactionRepository, l LogRepository, uow UnitOfWork) TerminalUseCase {
+	return &terminalService{
+		repo:         r,
+		merchantRepo: mr,
+		txRepo:       tr,
+		logRepo:      l,
+		uow:          uow,
+	}
+}
+
+// RegisterTerminal adds an active POS device to the merchant, closed
+// merchants can't get new terminals
+func (s *terminalService) RegisterTerminal(ctx context.Context, actor string, merchantID uuid.UUID, serialNumber, model string) (*entity.Terminal, error) {
+	merchant, err := s.merchantRepo.GetMerchantByID(ctx, merchantID)
+	if err != nil {
+		return nil, errors.New("merchant not found")
+	}
+	if merchant.Status == entity.MerchantClosed {
+		return nil, &entity.RejectedError{Code: entity.CodeMerchantNotActive, Reason: "merchant is closed"}
+	}
+
+	now := time.Now()
+	t := &entity.Terminal{
+		ID:           uuid.New(),
+		MerchantID:   merchantID,
+		SerialNumber: serialNumber,
+		Model:        model,
+		Status:       entity.TerminalActive,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateTerminal(ctx, t); err != nil {
+			return err
+		}
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:             uuid.New(),
+			Action:         entity.ActionTerminalRegistered,
+			Actor:          actor,
+			ResourceID:     t.ID.String(),
+			PrevResourceID: fmt.Sprintf("merchant:%s", merchantID),
+			After:          entity.Snapshot(t),
+			Timestamp:      now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *terminalService) GetTerminal(ctx context.Context, id uuid.UUID) (*entity.Terminal, error) {
+	t, err := s.repo.GetTerminalByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("terminal not found")
+	}
+	return t, nil
+}
+
+func (s *terminalService) GetMerchantTerminals(ctx context.Context, merchantID uuid.UUID) ([]entity.Terminal, error) {
+	if _, err := s.merchantRepo.GetMerchantByID(ctx, merchantID); err != nil {
+		return nil, errors.New("merchant not found")
+	}
+	return s.repo.ListMerchantTerminals(ctx, merchantID)
+}
+
+// DeactivateTerminal retires the device for good, its past transactions
+// stay attributed to it
+func (s *terminalService) DeactivateTerminal(ctx context.Context, actor string, id uuid.UUID, reason string) (*entity.Terminal, error) {
+	if reason == "" {
+		return nil, &entity.ValidationError{Field: "reason", Reason: "is required"}
+	}
+
+	var t *entity.Terminal
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if t, err = s.repo.GetTerminalByID(ctx, id); err != nil {
+			return errors.New("terminal not found")
+		}
+		if t.Status != entity.TerminalActive {
+			return &entity.RejectedError{Code: entity.CodeInvalidStatusTransition, Reason: "terminal is already " + string(t.Status)}
+		}
+
+		before := entity.Snapshot(t)
+		now := time.Now()
+		t.Status = entity.TerminalInactive
+		t.StatusReason = reason
+		t.StatusChangedAt = &now
+		t.UpdatedAt = now
+		if err := s.repo.UpdateTerminal(ctx, t); err != nil {
+			return err
+		}
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:             uuid.New(),
+			Action:         entity.ActionTerminalDeactivated,
+			Actor:          actor,
+			ResourceID:     id.String(),
+			PrevResourceID: fmt.Sprintf("status:%s", entity.TerminalActive),
+			Before:         before,
+			After:          entity.Snapshot(t),
+			Timestamp:      now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *terminalService) GetTerminalTransactions(ctx context.Context, id uuid.UUID) ([]entity.Transaction, error) {
+	if _, err := s.repo.GetTerminalByID(ctx, id); err != nil {
+		return nil, errors.New("terminal not found")
+	}
+	return s.txRepo.TransactionListByTerminal(ctx, id)
+}
@@ -15,49 +15,120 @@ type transactionService struct {
 	merchantRepo MerchantRepository
 	bizRepo      BusinessRepository
 	logRepo      LogRepository
+	summaryRepo  SummaryRepository
+	terminalRepo TerminalRepository
+	uow          UnitOfWork
 }
 
-func NewTransactionService(tr TransactionRepository, mr MerchantRepository, br BusinessRepository, lr LogRepository) TransactionUseCase {
-	return &transactionService{tr, mr, br, lr}
+func NewTransactionService(tr TransactionRepository, mr MerchantRepository, br BusinessRepository, lr LogRepository, sr SummaryRepository, tmr TerminalRepository, uow UnitOfWork) TransactionUseCase {
+	return &transactionService{tr, mr, br, lr, sr, tmr, uow}
 }
 
-func (s *transactionService) ProcessTransaction(ctx context.Context, actor string, mID uuid.UUID, amount int64) (*entity.Transaction, error) {
+// CalculateFee is the single fee formula of the system, rate is in basis
+// points (e.g., 550 for 5.5%): Fee = (Amount * 550) / 10000
+func CalculateFee(amount, rate int64) int64 {
+	return (amount * rate) / 10000
+}
+
+// ProcessTransaction charges the merchant's effective rate. terminalID is
+// optional, when set it must be an active terminal of the merchant.
+func (s *transactionService) ProcessTransaction(ctx context.Context, actor string, mID uuid.UUID, amount int64, terminalID *uuid.UUID) (*entity.Transaction, error) {
 
 	merchant, err := s.merchantRepo.GetMerchantByID(ctx, mID)
 	if err != nil {
 		return nil, errors.New("merchant not found")
 	}
+	if merchant.Status != entity.MerchantActive {
+		return nil, &entity.RejectedError{Code: entity.CodeMerchantNotActive, Reason: fmt.Sprintf("merchant is %s", merchant.Status)}
+	}
+
+	if terminalID != nil {
+		terminal, err := s.terminalRepo.GetTerminalByID(ctx, *terminalID)
+		if err != nil || terminal.MerchantID != mID {
+			return nil, &entity.ValidationError{Field: "terminal_id", Reason: "is not a terminal of the merchant"}
+		}
+		if terminal.Status != entity.TerminalActive {
+			return nil, &entity.RejectedError{Code: entity.CodeTerminalNotActive, Reason: fmt.Sprintf("terminal %s is %s", terminal.SerialNumber, terminal.Status)}
+		}
+	}
 
 	biz, err := s.bizRepo.GetBusinessByID(ctx, merchant.BusinessID)
 	if err != nil {
 		return nil, errors.New("business configuration missing")
 	}
+	if biz.Status == entity.BusinessSuspended {
+		return nil, &entity.RejectedError{Code: entity.CodeBusinessSuspended, Reason: "business is suspended"}
+	}
 
-	// If biz.Commission is in basis points (e.g., 550 for 5.5%)
-	// Commission = (Amount * 550) / 10000
-	commission := (amount * biz.Commission) / 10000
+	now := time.Now()
+	rate, source := merchant.EffectiveCommission(*biz, now)
+	commission := CalculateFee(amount, rate)
 
 	tx := &entity.Transaction{
-		ID:         uuid.New(),
-		MerchantID: mID,
-		Amount:     amount,
-		Commission: biz.Commission,
-		Fee:        commission,
-		Timestamp:  time.Now(),
+		ID:               uuid.New(),
+		MerchantID:       mID,
+		BusinessID:       merchant.BusinessID,
+		TerminalID:       terminalID,
+		MCC:              merchant.MCC,
+		Amount:           amount,
+		Commission:       rate,
+		CommissionSource: source,
+		Fee:              commission,
+		Timestamp:        now,
 	}
 
-	if err := s.repo.CreateTransaction(ctx, tx); err != nil {
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateTransaction(ctx, tx); err != nil {
+			return err
+		}
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:         uuid.New(),
+			Action:     entity.ActionTransactionCreated,
+			Actor:      actor,
+			ResourceID: tx.ID.String(),
+			After:      entity.Snapshot(tx),
+			Timestamp:  time.Now(),
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	s.logRepo.CreateLog(ctx, &entity.Log{
-		ID:         uuid.New(),
-		Action:     "TRANSACTION_CREATED",
-		Actor:      actor,
-		ResourceID: tx.ID.String(),
-		Timestamp:  time.Now(),
-	})
+	return tx, nil
+}
 
+// RefundTransaction refunds the full amount of a transaction, the refund is
+// counted in the merchant's daily summary of today
+func (s *transactionService) RefundTransaction(ctx context.Context, actor string, id uuid.UUID) (*entity.Transaction, error) {
+	var tx *entity.Transaction
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if tx, err = s.repo.GetTransactionByID(ctx, id); err != nil {
+			return err
+		}
+		if tx.RefundedAt != nil {
+			return &entity.RejectedError{Code: entity.CodeAlreadyRefunded, Reason: "transaction is already refunded"}
+		}
+		before := entity.Snapshot(tx)
+
+		now := time.Now()
+		if err := s.repo.RefundTransaction(ctx, id, now); err != nil {
+			return err
+		}
+		tx.RefundedAt = &now
+		return s.logRepo.CreateLog(ctx, &entity.Log{
+			ID:         uuid.New(),
+			Action:     entity.ActionTransactionRefunded,
+			Actor:      actor,
+			ResourceID: id.String(),
+			Before:     before,
+			After:      entity.Snapshot(tx),
+			Timestamp:  now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
 	return tx, nil
 }
 
@@ -81,28 +152,30 @@ func (s *transactionService) GetMerchantTransactions(ctx context.Context, mercha
 func (s *transactionService) GetAllTransactions(ctx context.Context) ([]entity.Transaction, error) {
 	return s.repo.GetAllTransaction(ctx)
 }
+
+// Revenue is read from the daily summaries, never from a full table scan
 func (s *transactionService) GetAllRevenue(ctx context.Context) (float64, error) {
-	tx, err := s.repo.GetAllTransaction(ctx)
+	sum, err := s.summaryRepo.SumDailySummaries(ctx, nil)
 	if err != nil {
 		return 0, errors.New("unk error")
 	}
-	total := 0.0
-	for _, t := range tx {
-		total += float64(t.Fee)
-	}
 
-	return total / 100, nil
+	return float64(sum.Fees) / 100, nil
 }
 
 func (s *transactionService) GetAllRevenueByMerchant(ctx context.Context, merchantID uuid.UUID) (float64, error) {
-	tx, err := s.repo.TransactionListByMerchant(ctx, merchantID)
+	sum, err := s.summaryRepo.SumDailySummaries(ctx, &merchantID)
 	if err != nil {
 		return 0, errors.New("unk error")
 	}
-	total := 0.0
-	for _, t := range tx {
-		total += float64(t.Fee)
+
+	return float64(sum.Fees) / 100, nil
+}
+
+func (s *transactionService) GetMerchantDailySummaries(ctx context.Context, merchantID uuid.UUID, from, to time.Time) ([]entity.DailySummary, error) {
+	if _, err := s.merchantRepo.GetMerchantByID(ctx, merchantID); err != nil {
+		return nil, errors.New("merchant not found")
 	}
 
-	return total / 100, nil
+	return s.summaryRepo.ListDailySummaries(ctx, merchantID, from, to)
 }